@@ -1,17 +1,27 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
+	"net/http"
 	"os"
+	"path/filepath"
+	"strings"
 	"text/tabwriter"
 
 	"github.com/spf13/cobra"
 
 	"github.com/brattlof/zeptor/internal/app/config"
+	"github.com/brattlof/zeptor/internal/app/i18n"
+	"github.com/brattlof/zeptor/internal/app/render"
 	"github.com/brattlof/zeptor/internal/app/router"
+	"github.com/brattlof/zeptor/internal/app/server"
+	"github.com/brattlof/zeptor/internal/codegen"
 	"github.com/brattlof/zeptor/internal/dev"
 	"github.com/brattlof/zeptor/internal/scaffold"
 	"github.com/brattlof/zeptor/pkg/plugin"
@@ -80,9 +90,46 @@ var buildCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		ssg, _ := cmd.Flags().GetBool("ssg")
 		outDir, _ := cmd.Flags().GetString("out")
+		configPath, _ := cmd.Flags().GetString("config")
+
+		cfg, err := config.Load(configPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+			os.Exit(1)
+		}
+
+		if outDir == "" {
+			outDir = cfg.Build.StaticDir
+		}
 
 		fmt.Printf("Building (SSG: %v, out: %s)\n", ssg, outDir)
-		fmt.Println("Build not yet implemented - coming in Phase 4")
+
+		builder := dev.NewBuilder(cfg.Routing.AppDir, outDir, cfg.Hosts...)
+		if err := builder.GenerateRoutes(cmd.Context()); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Generated %s\n", codegen.RoutesFile)
+
+		var messagesDir string
+		if cfg.I18n.Enabled() {
+			messagesDir = cfg.I18n.MessagesDir
+		}
+		manifest, routes, err := builder.WriteManifest(cmd.Context(), cfg.Build.OutDir, messagesDir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Generated %s (%d routes)\n", manifest, routes)
+
+		if !ssg {
+			fmt.Println("Binary build not yet implemented - coming in Phase 4")
+			return
+		}
+		if err := builder.BuildSSG(cmd.Context()); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
 	},
 }
 
@@ -104,10 +151,35 @@ var generateCmd = &cobra.Command{
   zt generate page about        Create app/about/page.templ
   zt generate api users         Create app/api/users/route.go
   zt generate layout admin      Create app/admin/layout.templ
-  zt generate component Button  Create components/Button.templ`,
-	Args: cobra.MinimumNArgs(2),
+  zt generate component Button  Create components/Button.templ
+  zt generate routes            Create zeptor_routes_gen.go from app/`,
+	Args: cobra.RangeArgs(1, 2),
 	Run: func(cmd *cobra.Command, args []string) {
 		genType := args[0]
+
+		if genType == "routes" || genType == "r" {
+			configPath, _ := cmd.Flags().GetString("config")
+
+			cfg, err := config.Load(configPath)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+				os.Exit(1)
+			}
+
+			result, err := codegen.GenerateRoutes(cfg.Routing.AppDir, codegen.RoutesFile, cfg.Hosts...)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error generating routes: %v\n", err)
+				os.Exit(1)
+			}
+
+			fmt.Printf("Generated %s (%d routes) and %s\n", result.File, result.Routes, result.URLs)
+			return
+		}
+
+		if len(args) < 2 {
+			fmt.Fprintf(os.Stderr, "Missing name for %s\n", genType)
+			os.Exit(1)
+		}
 		name := args[1]
 
 		switch genType {
@@ -192,6 +264,7 @@ var routesCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		configPath, _ := cmd.Flags().GetString("config")
 		jsonOutput, _ := cmd.Flags().GetBool("json")
+		check, _ := cmd.Flags().GetBool("check")
 
 		cfg, err := config.Load(configPath)
 		if err != nil {
@@ -200,10 +273,80 @@ var routesCmd = &cobra.Command{
 		}
 
 		rt, err := router.New(cfg.Routing.AppDir)
-		if err != nil {
+
+		var diags router.Diagnostics
+		if err != nil && !errors.As(err, &diags) {
 			fmt.Fprintf(os.Stderr, "Error creating router: %v\n", err)
 			os.Exit(1)
 		}
+		if rt != nil {
+			diags = rt.Diagnostics()
+			for _, h := range cfg.Hosts {
+				if err := rt.AddHost(h.Host, h.AppDir, h.Prefix); err != nil {
+					var hostDiags router.Diagnostics
+					if errors.As(err, &hostDiags) {
+						diags = append(diags, hostDiags...)
+						continue
+					}
+					diags = append(diags, router.Diagnostic{
+						Severity: router.SeverityError,
+						Kind:     router.DiagnosticInvalidHost,
+						Files:    []string{configFile(configPath)},
+						Message:  err.Error(),
+					})
+				}
+			}
+		}
+		var bundle *i18n.Bundle
+		if cfg.I18n.Enabled() {
+			var msgDiags router.Diagnostics
+			bundle, msgDiags = checkMessages(cfg, configPath)
+			diags = append(diags, msgDiags...)
+		}
+		if bundle != nil && rt != nil {
+			for _, route := range bundle.Shadowed(rt) {
+				diags = append(diags, router.Diagnostic{
+					Severity: router.SeverityWarning,
+					Kind:     router.DiagnosticShadowed,
+					Pattern:  route.Pattern,
+					Files:    []string{route.File},
+					Message:  fmt.Sprintf("%s starts with a locale, so it is only reached behind another one, e.g. /%s%s", route.Pattern, bundle.Default, route.Pattern),
+				})
+			}
+		}
+		for _, err := range server.ValidateRules(cfg) {
+			diags = append(diags, router.Diagnostic{
+				Severity: router.SeverityError,
+				Kind:     router.DiagnosticInvalidRule,
+				Files:    []string{configFile(configPath)},
+				Message:  err.Error(),
+			})
+		}
+		modes, modeErrs := server.NewRenderModes(cfg)
+		for _, err := range modeErrs {
+			diags = append(diags, router.Diagnostic{
+				Severity: router.SeverityError,
+				Kind:     router.DiagnosticInvalidRenderMode,
+				Files:    []string{configFile(configPath)},
+				Message:  err.Error(),
+			})
+		}
+		if rt != nil {
+			diags = append(diags, checkRenderModes(rt)...)
+		}
+
+		if check || rt == nil {
+			if jsonOutput {
+				data, _ := json.MarshalIndent(map[string]interface{}{"diagnostics": diags}, "", "  ")
+				fmt.Println(string(data))
+			} else {
+				printDiagnostics(diags, cfg.Routing.AppDir)
+			}
+			if diags.HasErrors() {
+				os.Exit(1)
+			}
+			return
+		}
 
 		routes := rt.Routes()
 
@@ -217,14 +360,48 @@ var routesCmd = &cobra.Command{
 					routeType = "layout"
 				}
 				output[i] = map[string]interface{}{
-					"pattern": r.Pattern,
-					"type":    routeType,
-					"file":    r.File,
-					"dynamic": r.IsDynamic,
-					"params":  r.Params,
+					"pattern":     r.Pattern,
+					"type":        routeType,
+					"methods":     r.Methods,
+					"file":        r.File,
+					"dynamic":     r.IsDynamic,
+					"params":      r.Params,
+					"constraints": r.Constraints(),
+					"layouts":     layoutDirs(r),
+					"middleware":  middlewareDirs(rt, r),
+					"metadata":    r.Metadata,
+				}
+				if r.Type == router.RouteTypePage {
+					output[i]["render"] = renderMode(modes.For(r))
 				}
 			}
-			data, _ := json.MarshalIndent(map[string]interface{}{"routes": output}, "", "  ")
+			layouts := make([]map[string]interface{}, len(rt.Layouts()))
+			for i, l := range rt.Layouts() {
+				layouts[i] = map[string]interface{}{
+					"dir":     l.Dir,
+					"pattern": l.Pattern,
+					"file":    l.File,
+				}
+			}
+			boundaries := make([]map[string]interface{}, len(rt.Boundaries()))
+			for i, b := range rt.Boundaries() {
+				boundaries[i] = map[string]interface{}{
+					"kind":    b.Kind,
+					"dir":     b.Dir,
+					"pattern": b.Pattern,
+					"file":    b.File,
+				}
+			}
+			data, _ := json.MarshalIndent(map[string]interface{}{
+				"routes":      output,
+				"layouts":     layouts,
+				"boundaries":  boundaries,
+				"hosts":       hostRoutes(rt),
+				"locales":     localeInfo(bundle),
+				"redirects":   redirectRules(cfg),
+				"rewrites":    rewriteRules(cfg),
+				"diagnostics": diags,
+			}, "", "  ")
 			fmt.Println(string(data))
 			return
 		}
@@ -237,14 +414,11 @@ var routesCmd = &cobra.Command{
 		fmt.Printf("Found %d route(s) in %s:\n\n", len(routes), cfg.Routing.AppDir)
 
 		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-		fmt.Fprintln(w, "METHOD\tPATTERN\tTYPE\tFILE")
-		fmt.Fprintln(w, "------\t-------\t----\t----")
+		fmt.Fprintln(w, "METHOD\tPATTERN\tTYPE\tMODE\tLAYOUTS\tFILE")
+		fmt.Fprintln(w, "------\t-------\t----\t----\t-------\t----")
 
 		for _, r := range routes {
-			method := "GET"
-			if r.Type == router.RouteTypeAPI {
-				method = "*"
-			}
+			method := strings.Join(r.Methods, ",")
 
 			routeType := "page"
 			if r.Type == router.RouteTypeAPI {
@@ -258,7 +432,21 @@ var routesCmd = &cobra.Command{
 				dynamic = " [dynamic]"
 			}
 
-			fmt.Fprintf(w, "%s\t%s%s\t%s\t%s\n", method, r.Pattern, dynamic, routeType, r.File)
+			layouts := strings.Join(layoutDirs(r), " > ")
+			if layouts == "" {
+				layouts = "-"
+			}
+
+			mode := "-"
+			if r.Type == router.RouteTypePage {
+				m := modes.For(r)
+				mode = m.String()
+				if m.Source != server.ModeSourceDefault {
+					mode += " (" + m.Source + ")"
+				}
+			}
+
+			fmt.Fprintf(w, "%s\t%s%s\t%s\t%s\t%s\t%s\n", method, r.Pattern, dynamic, routeType, mode, layouts, r.File)
 		}
 		w.Flush()
 
@@ -266,6 +454,314 @@ var routesCmd = &cobra.Command{
 		for _, l := range rt.Layouts() {
 			fmt.Printf("  %s -> %s\n", l.Pattern, l.File)
 		}
+
+		if bundle != nil {
+			fmt.Printf("\nLocales: %s (default: %s), served under /{locale}%s\n",
+				strings.Join(bundle.Locales, ", "), bundle.Default, defaultPrefixNote(bundle))
+		}
+
+		if len(rt.Hosts()) > 0 {
+			fmt.Printf("\nHosts: %d\n", len(rt.Hosts()))
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			for _, h := range rt.Hosts() {
+				if h.Router != nil {
+					fmt.Fprintf(w, "  %s\t-> %s\t(%d routes)\n", h.Pattern, h.AppDir, len(h.Router.Routes()))
+				} else {
+					fmt.Fprintf(w, "  %s\t-> %s\n", h.Pattern, h.Prefix)
+				}
+			}
+			w.Flush()
+		}
+
+		if len(cfg.Redirects) > 0 || len(cfg.Rewrites) > 0 {
+			fmt.Printf("\nRules (trailing slash: %s, case: %s):\n", cfg.Routing.TrailingSlash, cfg.Routing.Case)
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			for _, r := range redirectRules(cfg) {
+				fmt.Fprintf(w, "  redirect %d\t%s\t-> %s%s\n", r["status"], r["source"], r["destination"], conditionSuffix(r))
+			}
+			for _, r := range rewriteRules(cfg) {
+				fmt.Fprintf(w, "  rewrite\t%s\t-> %s%s\n", r["source"], r["destination"], conditionSuffix(r))
+			}
+			w.Flush()
+		}
+
+		if len(diags) > 0 {
+			fmt.Println()
+			printDiagnostics(diags, cfg.Routing.AppDir)
+		}
+	},
+}
+
+func printDiagnostics(diags router.Diagnostics, appDir string) {
+	if len(diags) == 0 {
+		fmt.Printf("No problems found in %s\n", appDir)
+		return
+	}
+
+	errCount := 0
+	for _, d := range diags {
+		if d.Severity == router.SeverityError {
+			errCount++
+		}
+		fmt.Fprintln(os.Stderr, d.String())
+	}
+	fmt.Fprintf(os.Stderr, "\n%d error(s), %d warning(s) in %s\n", errCount, len(diags)-errCount, appDir)
+}
+
+// checkMessages loads the message catalogs and reports keys missing from
+// any locale.
+func checkMessages(cfg *config.Config, configPath string) (*i18n.Bundle, router.Diagnostics) {
+	b, err := i18n.New(cfg.I18n, os.DirFS(cfg.Routing.AppDir))
+	if err != nil {
+		return nil, router.Diagnostics{{
+			Severity: router.SeverityError,
+			Kind:     router.DiagnosticInvalidLocale,
+			Files:    []string{configFile(configPath)},
+			Message:  err.Error(),
+		}}
+	}
+	missing, err := b.Check(cfg.Routing.AppDir)
+	if err != nil {
+		return b, router.Diagnostics{{
+			Severity: router.SeverityError,
+			Kind:     router.DiagnosticInvalidLocale,
+			Files:    []string{filepath.Join(cfg.Routing.AppDir, b.Dir)},
+			Message:  err.Error(),
+		}}
+	}
+
+	var diags router.Diagnostics
+	for _, m := range missing {
+		files := []string{m.File}
+		if m.UsedIn != "" {
+			files = append(files, m.UsedIn)
+		}
+		diags = append(diags, router.Diagnostic{
+			Severity: router.SeverityWarning,
+			Kind:     router.DiagnosticMissingMessage,
+			Files:    files,
+			Message:  fmt.Sprintf("%s: missing message %q", m.Locale, m.Key),
+		})
+	}
+	return b, diags
+}
+
+// checkRenderModes warns about page metadata naming an unknown render
+// mode, which is rendered with the default mode instead.
+func checkRenderModes(rt *router.Router) router.Diagnostics {
+	var diags router.Diagnostics
+	for _, r := range rt.Routes() {
+		if r.Type != router.RouteTypePage || r.Metadata.Render == "" {
+			continue
+		}
+		if _, ok := render.LookupRenderMode(r.Metadata.Render); !ok {
+			diags = append(diags, router.Diagnostic{
+				Severity: router.SeverityWarning,
+				Kind:     router.DiagnosticInvalidRenderMode,
+				Pattern:  r.Pattern,
+				Files:    []string{r.File},
+				Message:  fmt.Sprintf("unknown render mode %q in metadata (want ssr, ssg or isr)", r.Metadata.Render),
+			})
+		}
+	}
+	return diags
+}
+
+func renderMode(m server.RouteMode) map[string]interface{} {
+	mode := map[string]interface{}{
+		"mode":   m.Mode.String(),
+		"source": m.Source,
+	}
+	if m.Revalidate > 0 {
+		mode["revalidate"] = int(m.Revalidate.Seconds())
+	}
+	return mode
+}
+
+func localeInfo(b *i18n.Bundle) map[string]interface{} {
+	if b == nil {
+		return nil
+	}
+	return map[string]interface{}{
+		"locales":       b.Locales,
+		"default":       b.Default,
+		"prefixDefault": b.PrefixDefault,
+		"messages":      b.Dir,
+	}
+}
+
+func defaultPrefixNote(b *i18n.Bundle) string {
+	if b.PrefixDefault {
+		return ""
+	}
+	return ", " + b.Default + " also without a prefix"
+}
+
+func hostRoutes(rt *router.Router) []map[string]interface{} {
+	hosts := make([]map[string]interface{}, len(rt.Hosts()))
+	for i, h := range rt.Hosts() {
+		host := map[string]interface{}{
+			"host":   h.Pattern,
+			"params": h.Params,
+		}
+		if h.Router != nil {
+			patterns := make([]string, len(h.Router.Routes()))
+			for j, r := range h.Router.Routes() {
+				patterns[j] = r.Pattern
+			}
+			host["appDir"] = h.AppDir
+			host["routes"] = patterns
+		} else {
+			host["prefix"] = h.Prefix
+		}
+		hosts[i] = host
+	}
+	return hosts
+}
+
+func redirectRules(cfg *config.Config) []map[string]interface{} {
+	rules := make([]map[string]interface{}, len(cfg.Redirects))
+	for i, r := range cfg.Redirects {
+		status := http.StatusTemporaryRedirect
+		if r.Permanent {
+			status = http.StatusPermanentRedirect
+		}
+		rules[i] = map[string]interface{}{
+			"source":      r.Source,
+			"destination": r.Destination,
+			"status":      status,
+			"conditions":  ruleConditions(r.Has, r.Missing),
+		}
+	}
+	return rules
+}
+
+func rewriteRules(cfg *config.Config) []map[string]interface{} {
+	rules := make([]map[string]interface{}, len(cfg.Rewrites))
+	for i, r := range cfg.Rewrites {
+		rules[i] = map[string]interface{}{
+			"source":      r.Source,
+			"destination": r.Destination,
+			"conditions":  ruleConditions(r.Has, r.Missing),
+		}
+	}
+	return rules
+}
+
+// ruleConditions formats has and missing conditions as e.g.
+// "header:X-Beta=1 !cookie:session".
+func ruleConditions(has, missing []config.RuleCondition) string {
+	var parts []string
+	format := func(prefix string, c config.RuleCondition) {
+		part := prefix + c.Type + ":" + c.Key
+		if c.Value != "" {
+			part += "=" + c.Value
+		}
+		parts = append(parts, part)
+	}
+	for _, c := range has {
+		format("", c)
+	}
+	for _, c := range missing {
+		format("!", c)
+	}
+	return strings.Join(parts, " ")
+}
+
+func conditionSuffix(rule map[string]interface{}) string {
+	if conds := rule["conditions"].(string); conds != "" {
+		return "  [" + conds + "]"
+	}
+	return ""
+}
+
+func configFile(configPath string) string {
+	if configPath != "" {
+		return configPath
+	}
+	return "zeptor.config.yaml"
+}
+
+func layoutDirs(r *router.Route) []string {
+	dirs := make([]string, len(r.Layouts))
+	for i, l := range r.Layouts {
+		dirs[i] = l.Dir
+	}
+	return dirs
+}
+
+func middlewareDirs(rt *router.Router, r *router.Route) []string {
+	dirs := make([]string, 0)
+	if r.Dir == "" {
+		return dirs
+	}
+	for _, m := range rt.MiddlewareChain(r.Dir) {
+		dirs = append(dirs, m.Dir)
+	}
+	return dirs
+}
+
+var revalidateCmd = &cobra.Command{
+	Use:   "revalidate [path...]",
+	Short: "Purge pages from a running server's ISR cache",
+	Long: `Purge the given paths, and pages tagged with --tag, from the ISR cache of a
+running server. The server must set rendering.revalidateSecret; the secret is read
+from the config, ZEPTOR_REVALIDATE_SECRET or --secret.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		configPath, _ := cmd.Flags().GetString("config")
+		tags, _ := cmd.Flags().GetStringSlice("tag")
+		baseURL, _ := cmd.Flags().GetString("url")
+		secret, _ := cmd.Flags().GetString("secret")
+
+		if len(args) == 0 && len(tags) == 0 {
+			fmt.Fprintln(os.Stderr, "Error: give at least one path or --tag")
+			os.Exit(1)
+		}
+
+		cfg, err := config.Load(configPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+			os.Exit(1)
+		}
+		if secret == "" {
+			secret = cfg.Rendering.RevalidateSecret
+		}
+		if secret == "" {
+			fmt.Fprintln(os.Stderr, "Error: no revalidate secret; set rendering.revalidateSecret or ZEPTOR_REVALIDATE_SECRET")
+			os.Exit(1)
+		}
+		if baseURL == "" {
+			baseURL = fmt.Sprintf("http://localhost:%d", cfg.App.Port)
+		}
+
+		body, _ := json.Marshal(server.RevalidateRequest{Paths: args, Tags: tags})
+		req, err := http.NewRequestWithContext(cmd.Context(), http.MethodPost, strings.TrimSuffix(baseURL, "/")+server.RevalidatePath, bytes.NewReader(body))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+secret)
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			msg, _ := io.ReadAll(resp.Body)
+			fmt.Fprintf(os.Stderr, "Error: %s: %s\n", resp.Status, strings.TrimSpace(string(msg)))
+			os.Exit(1)
+		}
+
+		var result server.RevalidateResponse
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Revalidated %d cached pages\n", result.Purged)
 	},
 }
 
@@ -386,14 +882,17 @@ func init() {
 	devCmd.Flags().Bool("no-ebpf", false, "Disable eBPF acceleration")
 	devCmd.Flags().StringP("config", "c", "", "Path to config file")
 
-	buildCmd.Flags().Bool("ssg", false, "Enable static site generation")
-	buildCmd.Flags().StringP("out", "o", "./dist", "Output directory")
+	buildCmd.Flags().Bool("ssg", false, "Pre-render pages into the output directory")
+	buildCmd.Flags().StringP("out", "o", "", "Output directory (default build.staticDir)")
 	buildCmd.Flags().StringP("config", "c", "", "Path to config file")
 
 	startCmd.Flags().IntP("port", "p", 3000, "Port to run server on")
 	startCmd.Flags().StringP("config", "c", "", "Path to config file")
 
+	generateCmd.Flags().StringP("config", "c", "", "Path to config file")
+
 	routesCmd.Flags().BoolP("json", "j", false, "Output as JSON")
+	routesCmd.Flags().Bool("check", false, "Report route conflicts and exit non-zero on errors")
 	routesCmd.Flags().StringP("config", "c", "", "Path to config file")
 
 	createCmd.Flags().StringP("template", "t", "minimal", "Project template (minimal, basic, api)")
@@ -402,6 +901,11 @@ func init() {
 	createCmd.Flags().Bool("skip-templ", false, "Skip templ generate")
 	createCmd.Flags().StringP("output", "o", "", "Output directory (default: project name)")
 
+	revalidateCmd.Flags().StringSliceP("tag", "t", nil, "Purge pages with this cache tag (repeatable)")
+	revalidateCmd.Flags().String("url", "", "Server URL (default http://localhost:<app.port>)")
+	revalidateCmd.Flags().String("secret", "", "Revalidate secret (default rendering.revalidateSecret)")
+	revalidateCmd.Flags().StringP("config", "c", "", "Path to config file")
+
 	pluginListCmd.Flags().BoolP("json", "j", false, "Output as JSON")
 	pluginListCmd.Flags().StringP("config", "c", "", "Path to config file")
 
@@ -417,6 +921,7 @@ func init() {
 	rootCmd.AddCommand(versionCmd)
 	rootCmd.AddCommand(routesCmd)
 	rootCmd.AddCommand(createCmd)
+	rootCmd.AddCommand(revalidateCmd)
 	rootCmd.AddCommand(pluginCmd)
 }
 
@@ -0,0 +1,11 @@
+package api
+
+import "net/http"
+
+// Middleware keeps API responses out of shared caches.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-store")
+		next.ServeHTTP(w, r)
+	})
+}
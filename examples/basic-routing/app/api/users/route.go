@@ -17,23 +17,21 @@ var users = []User{
 	{ID: 3, Name: "Charlie", Email: "charlie@example.com"},
 }
 
-func Handler(w http.ResponseWriter, r *http.Request) {
+func GET(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(users)
+}
 
-	switch r.Method {
-	case http.MethodGet:
-		json.NewEncoder(w).Encode(users)
-	case http.MethodPost:
-		var user User
-		if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
-			http.Error(w, err.Error(), http.StatusBadRequest)
-			return
-		}
-		user.ID = len(users) + 1
-		users = append(users, user)
-		w.WriteHeader(http.StatusCreated)
-		json.NewEncoder(w).Encode(user)
-	default:
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+func POST(w http.ResponseWriter, r *http.Request) {
+	var user User
+	if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
+	user.ID = len(users) + 1
+	users = append(users, user)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(user)
 }
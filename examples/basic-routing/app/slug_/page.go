@@ -0,0 +1,34 @@
+package slug_
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/brattlof/zeptor/examples/basic-routing/routes"
+	"github.com/brattlof/zeptor/internal/app/router"
+)
+
+// Props is what Load passes to Page, and what /{slug}?_data returns.
+type Props struct {
+	Slug  string `json:"slug"`
+	Title string `json:"title"`
+}
+
+// Load runs before Page renders. Slugs ending in .html redirect to their
+// canonical URL, and a few reserved ones render the not-found page.
+func Load(ctx context.Context, r *http.Request) (Props, error) {
+	slug := router.Param(r, "slug")
+	if canonical, ok := strings.CutSuffix(slug, ".html"); ok {
+		return Props{}, router.PermanentRedirect(routes.BySlug(canonical))
+	}
+	if slug == "admin" || slug == "wp-login.php" {
+		return Props{}, router.NotFound()
+	}
+
+	title := strings.ReplaceAll(slug, "-", " ")
+	if title != "" {
+		title = strings.ToUpper(title[:1]) + title[1:]
+	}
+	return Props{Slug: slug, Title: title}, nil
+}
@@ -0,0 +1,9 @@
+package slug_
+
+// StaticParams lists the slugs zt build --ssg pre-renders.
+func StaticParams() []map[string]string {
+	return []map[string]string{
+		{"slug": "hello-world"},
+		{"slug": "getting-started"},
+	}
+}
@@ -3,96 +3,83 @@ package main
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
 
-	"github.com/go-chi/chi/v5"
-	"github.com/go-chi/chi/v5/middleware"
-
-	"github.com/brattlof/zeptor/examples/basic-routing/app"
-	"github.com/brattlof/zeptor/examples/basic-routing/app/about"
-	"github.com/brattlof/zeptor/examples/basic-routing/app/api/users"
-	"github.com/brattlof/zeptor/examples/basic-routing/app/slug_"
+	"github.com/brattlof/zeptor/internal/app/config"
+	"github.com/brattlof/zeptor/internal/app/router"
+	"github.com/brattlof/zeptor/internal/app/server"
 )
 
 func main() {
 	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}))
 	slog.SetDefault(logger)
 
-	r := chi.NewRouter()
-	r.Use(middleware.RequestID)
-	r.Use(middleware.RealIP)
-	r.Use(middleware.Logger)
-	r.Use(middleware.Recoverer)
-	r.Use(middleware.Timeout(60 * time.Second))
-
-	r.Get("/", func(w http.ResponseWriter, req *http.Request) {
-		w.Header().Set("Content-Type", "text/html; charset=utf-8")
-		if err := app.Page().Render(req.Context(), w); err != nil {
-			slog.Error("Failed to render home page", "error", err)
-		}
-	})
+	cfg, err := config.Load("zeptor.config.yaml")
+	if err != nil {
+		slog.Error("Failed to load config", "error", err)
+		os.Exit(1)
+	}
 
-	r.Get("/about", func(w http.ResponseWriter, req *http.Request) {
-		w.Header().Set("Content-Type", "text/html; charset=utf-8")
-		if err := about.Page().Render(req.Context(), w); err != nil {
-			slog.Error("Failed to render about page", "error", err)
-		}
-	})
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "3001"
+	}
+	cfg.App.Port, _ = strconv.Atoi(port)
 
-	r.Get("/{slug}", func(w http.ResponseWriter, req *http.Request) {
-		slug := chi.URLParam(req, "slug")
-		w.Header().Set("Content-Type", "text/html; charset=utf-8")
-		if err := slug_.Page(slug).Render(req.Context(), w); err != nil {
-			slog.Error("Failed to render dynamic page", "error", err)
-		}
-	})
+	rt, err := server.LoadRoutes(cfg)
+	if err != nil {
+		slog.Error("Failed to load routes", "error", err)
+		os.Exit(1)
+	}
+	registerRoutes(rt)
 
-	r.HandleFunc("/api/users", users.Handler)
+	s := server.New(cfg, rt, nil, logger)
+	s.SetupMiddlewares()
+	s.SetupRoutes()
 
-	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		fmt.Fprint(w, `{"status":"ok"}`)
-	})
-
-	r.Get("/api/routes", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		routes := []map[string]string{
-			{"pattern": "/", "type": "page"},
-			{"pattern": "/about", "type": "page"},
-			{"pattern": "/{slug}", "type": "page", "dynamic": "true"},
-			{"pattern": "/api/users", "type": "api"},
+	if ok, err := s.ExportIfRequested(context.Background()); ok {
+		if err != nil {
+			slog.Error("Static export failed", "error", err)
+			os.Exit(1)
 		}
-		data, _ := json.Marshal(map[string]interface{}{"routes": routes})
-		w.Write(data)
-	})
+		return
+	}
 
-	r.NotFound(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "text/html; charset=utf-8")
-		w.WriteHeader(http.StatusNotFound)
-		w.Write([]byte(`<!DOCTYPE html><html><body><h1>404 Not Found</h1></body></html>`))
+	s.Get("/api/routes", func(w http.ResponseWriter, r *http.Request) {
+		routes := make([]map[string]interface{}, 0, len(rt.Routes()))
+		for _, route := range rt.Routes() {
+			routeType := "page"
+			if route.Type == router.RouteTypeAPI {
+				routeType = "api"
+			}
+			routes = append(routes, map[string]interface{}{
+				"pattern": route.Pattern,
+				"type":    routeType,
+				"dynamic": route.IsDynamic,
+			})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"routes": routes})
 	})
 
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "3001"
-	}
-
 	srv := &http.Server{
 		Addr:         ":" + port,
-		Handler:      r,
+		Handler:      s.Handler(),
 		ReadTimeout:  15 * time.Second,
 		WriteTimeout: 15 * time.Second,
 		IdleTimeout:  60 * time.Second,
 	}
 
+	go s.ReloadOnSignal(context.Background())
+
 	go func() {
-		slog.Info("Server starting", "addr", srv.Addr)
+		slog.Info("Server starting", "addr", srv.Addr, "routes", len(rt.Routes()))
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			slog.Error("Server error", "error", err)
 			os.Exit(1)
@@ -0,0 +1,28 @@
+// Code generated by zt generate routes. DO NOT EDIT.
+
+package routes
+
+import (
+	"github.com/brattlof/zeptor/internal/app/router"
+)
+
+// Home returns the URL for /.
+func Home() string {
+	return "/"
+}
+
+// About returns the URL for /about.
+func About() string {
+	return "/about"
+}
+
+// APIUsers returns the URL for /api/users.
+func APIUsers() string {
+	return "/api/users"
+}
+
+// BySlug returns the URL for /{slug}. It panics if a value
+// cannot fill its segment.
+func BySlug(slug string) string {
+	return "/" + router.EscapeParam("slug", "", slug)
+}
@@ -0,0 +1,47 @@
+// Code generated by zt generate routes. DO NOT EDIT.
+
+package main
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/brattlof/zeptor/internal/app/render"
+	"github.com/brattlof/zeptor/internal/app/router"
+
+	app "github.com/brattlof/zeptor/examples/basic-routing/app"
+	about "github.com/brattlof/zeptor/examples/basic-routing/app/about"
+	api "github.com/brattlof/zeptor/examples/basic-routing/app/api"
+	users "github.com/brattlof/zeptor/examples/basic-routing/app/api/users"
+	slug_ "github.com/brattlof/zeptor/examples/basic-routing/app/slug_"
+)
+
+func registerRoutes(rt *router.Router) {
+	rt.HandleLayout("/", func(r *http.Request) (render.Component, error) {
+		return app.Layout(), nil
+	})
+	rt.HandleMiddleware("/api", api.Middleware)
+	rt.HandleBoundary(router.BoundaryError, "/", func(r *http.Request) (render.Component, error) {
+		return app.Error(router.RouteError(r)), nil
+	})
+	rt.HandleBoundary(router.BoundaryNotFound, "/", func(r *http.Request) (render.Component, error) {
+		return app.NotFound(), nil
+	})
+	rt.HandlePage("/", func(r *http.Request) (render.Component, error) {
+		return app.Page(), nil
+	})
+	rt.HandlePage("/about", func(r *http.Request) (render.Component, error) {
+		return about.Page(), nil
+	})
+	rt.HandleMethod("/api/users", http.MethodGet, users.GET)
+	rt.HandleMethod("/api/users", http.MethodPost, users.POST)
+	rt.HandlePage("/{slug}", func(r *http.Request) (render.Component, error) {
+		return slug_.Page(router.PageProps(r, slug_.Load)), nil
+	})
+	rt.HandleLoad("/{slug}", func(ctx context.Context, r *http.Request) (any, error) {
+		return slug_.Load(ctx, r)
+	})
+	rt.HandleStaticParams("/{slug}", func() ([]map[string]string, error) {
+		return slug_.StaticParams(), nil
+	})
+}
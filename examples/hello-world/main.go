@@ -2,64 +2,65 @@ package main
 
 import (
 	"context"
-	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
 
-	"github.com/go-chi/chi/v5"
-	"github.com/go-chi/chi/v5/middleware"
-
-	"github.com/brattlof/zeptor/examples/hello-world/app"
+	"github.com/brattlof/zeptor/internal/app/config"
+	"github.com/brattlof/zeptor/internal/app/server"
 )
 
 func main() {
 	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}))
 	slog.SetDefault(logger)
 
-	r := chi.NewRouter()
-	r.Use(middleware.RequestID)
-	r.Use(middleware.RealIP)
-	r.Use(middleware.Logger)
-	r.Use(middleware.Recoverer)
-	r.Use(middleware.Timeout(60 * time.Second))
-
-	r.Get("/", func(w http.ResponseWriter, req *http.Request) {
-		w.Header().Set("Content-Type", "text/html; charset=utf-8")
-		if err := app.Page().Render(req.Context(), w); err != nil {
-			slog.Error("Failed to render page", "error", err)
-		}
-	})
-
-	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		fmt.Fprint(w, `{"status":"ok"}`)
-	})
-
-	r.NotFound(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "text/html; charset=utf-8")
-		w.WriteHeader(http.StatusNotFound)
-		w.Write([]byte(`<!DOCTYPE html><html><body><h1>404 Not Found</h1></body></html>`))
-	})
+	cfg, err := config.Load("zeptor.config.yaml")
+	if err != nil {
+		slog.Error("Failed to load config", "error", err)
+		os.Exit(1)
+	}
 
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "3001"
 	}
+	cfg.App.Port, _ = strconv.Atoi(port)
+
+	rt, err := server.LoadRoutes(cfg)
+	if err != nil {
+		slog.Error("Failed to load routes", "error", err)
+		os.Exit(1)
+	}
+	registerRoutes(rt)
+
+	s := server.New(cfg, rt, nil, logger)
+	s.SetupMiddlewares()
+	s.SetupRoutes()
+
+	if ok, err := s.ExportIfRequested(context.Background()); ok {
+		if err != nil {
+			slog.Error("Static export failed", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
 
 	srv := &http.Server{
 		Addr:         ":" + port,
-		Handler:      r,
+		Handler:      s.Handler(),
 		ReadTimeout:  15 * time.Second,
 		WriteTimeout: 15 * time.Second,
 		IdleTimeout:  60 * time.Second,
 	}
 
+	go s.ReloadOnSignal(context.Background())
+
 	go func() {
-		slog.Info("Server starting", "addr", srv.Addr)
+		slog.Info("Server starting", "addr", srv.Addr, "routes", len(rt.Routes()))
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			slog.Error("Server error", "error", err)
 			os.Exit(1)
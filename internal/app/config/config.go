@@ -16,6 +16,10 @@ type Config struct {
 	Build     BuildConfig     `mapstructure:"build"`
 	Logging   LoggingConfig   `mapstructure:"logging"`
 	Plugins   PluginsConfig   `mapstructure:"plugins"`
+	Redirects []RedirectRule  `mapstructure:"redirects"`
+	Rewrites  []RewriteRule   `mapstructure:"rewrites"`
+	Hosts     []HostConfig    `mapstructure:"hosts"`
+	I18n      I18nConfig      `mapstructure:"i18n"`
 }
 
 type AppConfig struct {
@@ -24,8 +28,63 @@ type AppConfig struct {
 }
 
 type RoutingConfig struct {
-	AppDir    string `mapstructure:"appDir"`
-	PublicDir string `mapstructure:"publicDir"`
+	AppDir        string `mapstructure:"appDir"`
+	PublicDir     string `mapstructure:"publicDir"`
+	TrailingSlash string `mapstructure:"trailingSlash"`
+	Case          string `mapstructure:"case"`
+}
+
+// Trailing-slash policies: ignore matches /about and /about/ alike, never
+// and always redirect to the form without or with the slash.
+const (
+	TrailingSlashIgnore = "ignore"
+	TrailingSlashNever  = "never"
+	TrailingSlashAlways = "always"
+)
+
+// Case policies: sensitive routes paths as given, insensitive routes them
+// lowercased but keeps the case of param values, and lower redirects to the
+// lowercase path.
+const (
+	CaseSensitive   = "sensitive"
+	CaseInsensitive = "insensitive"
+	CaseLower       = "lower"
+)
+
+// HostConfig serves requests for Host, a hostname such as docs.example.com
+// or {tenant}.example.com, from its own AppDir or from the routes under
+// Prefix in the main app. Labels like {tenant} become route params.
+type HostConfig struct {
+	Host   string `mapstructure:"host"`
+	AppDir string `mapstructure:"appDir"`
+	Prefix string `mapstructure:"prefix"`
+}
+
+// RedirectRule sends requests matching Source, a route pattern such as
+// /blog/{slug}, to Destination with its params substituted.
+type RedirectRule struct {
+	Source      string          `mapstructure:"source"`
+	Destination string          `mapstructure:"destination"`
+	Permanent   bool            `mapstructure:"permanent"`
+	Has         []RuleCondition `mapstructure:"has"`
+	Missing     []RuleCondition `mapstructure:"missing"`
+}
+
+// RewriteRule serves Destination in place of Source without changing the
+// URL the client sees.
+type RewriteRule struct {
+	Source      string          `mapstructure:"source"`
+	Destination string          `mapstructure:"destination"`
+	Has         []RuleCondition `mapstructure:"has"`
+	Missing     []RuleCondition `mapstructure:"missing"`
+}
+
+// RuleCondition matches a header, query or cookie by key. Value is a
+// regular expression; when empty the key only has to be present.
+type RuleCondition struct {
+	Type  string `mapstructure:"type"`
+	Key   string `mapstructure:"key"`
+	Value string `mapstructure:"value"`
 }
 
 type EBPFConfig struct {
@@ -35,9 +94,46 @@ type EBPFConfig struct {
 	Interface string `mapstructure:"interface"`
 }
 
+// I18nConfig enables locale prefixes such as /fr/about for every route.
+// Messages are read from MessagesDir, relative to the app directory, as
+// one <locale>.yaml or <locale>.json per locale.
+type I18nConfig struct {
+	Locales       []string `mapstructure:"locales"`
+	DefaultLocale string   `mapstructure:"defaultLocale"`
+	PrefixDefault bool     `mapstructure:"prefixDefault"`
+	Detect        bool     `mapstructure:"detect"`
+	Cookie        string   `mapstructure:"cookie"`
+	MessagesDir   string   `mapstructure:"messagesDir"`
+}
+
+func (c I18nConfig) Enabled() bool {
+	return len(c.Locales) > 0
+}
+
 type RenderingConfig struct {
-	Mode           string `mapstructure:"mode"`
-	ISRRevalidateS int    `mapstructure:"isrRevalidateSec"`
+	Mode             string         `mapstructure:"mode"`
+	ISRRevalidateS   int            `mapstructure:"isrRevalidateSec"`
+	ISRMaxEntries    int            `mapstructure:"isrMaxEntries"`
+	RevalidateSecret string         `mapstructure:"revalidateSecret"`
+	Routes           []RenderRoute  `mapstructure:"routes"`
+	Fragments        FragmentConfig `mapstructure:"fragments"`
+}
+
+// FragmentConfig controls partial page requests. A request carrying
+// Header, or the Query parameter, gets the page without its layouts; the
+// parameter's values name templ fragments to render instead. Empty values
+// disable either.
+type FragmentConfig struct {
+	Header string `mapstructure:"header"`
+	Query  string `mapstructure:"query"`
+}
+
+// RenderRoute overrides the render mode, or the ISR interval, of the pages
+// whose patterns match Match, e.g. "/blog/{...rest}".
+type RenderRoute struct {
+	Match         string `mapstructure:"match"`
+	Mode          string `mapstructure:"mode"`
+	RevalidateSec int    `mapstructure:"revalidateSec"`
 }
 
 type BuildConfig struct {
@@ -93,6 +189,14 @@ func Load(configPath string) (*Config, error) {
 		v.Set("ebpf.enabled", ebpfEnv == "true")
 	}
 
+	if secret := os.Getenv("ZEPTOR_REVALIDATE_SECRET"); secret != "" {
+		v.Set("rendering.revalidateSecret", secret)
+	}
+
+	if staticDir := os.Getenv("ZEPTOR_STATIC_DIR"); staticDir != "" {
+		v.Set("build.staticDir", staticDir)
+	}
+
 	var cfg Config
 	if err := v.Unmarshal(&cfg); err != nil {
 		return nil, fmt.Errorf("error unmarshaling config: %w", err)
@@ -107,6 +211,12 @@ func setDefaults(v *viper.Viper) {
 
 	v.SetDefault("routing.appDir", "./app")
 	v.SetDefault("routing.publicDir", "./public")
+	v.SetDefault("routing.trailingSlash", TrailingSlashIgnore)
+	v.SetDefault("routing.case", CaseSensitive)
+
+	v.SetDefault("i18n.detect", true)
+	v.SetDefault("i18n.cookie", "locale")
+	v.SetDefault("i18n.messagesDir", "locales")
 
 	v.SetDefault("ebpf.enabled", true)
 	v.SetDefault("ebpf.interface", "eth0")
@@ -115,6 +225,9 @@ func setDefaults(v *viper.Viper) {
 
 	v.SetDefault("rendering.mode", "ssr")
 	v.SetDefault("rendering.isrRevalidateSec", 300)
+	v.SetDefault("rendering.isrMaxEntries", 1000)
+	v.SetDefault("rendering.fragments.header", "HX-Request")
+	v.SetDefault("rendering.fragments.query", "_fragment")
 
 	v.SetDefault("build.outDir", "./.zeptor")
 	v.SetDefault("build.staticDir", "./dist")
@@ -0,0 +1,102 @@
+package i18n
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// translateCall matches T(ctx, "key" in templ and Go sources.
+var translateCall = regexp.MustCompile(`\bT\(\s*[\w.]+\s*,\s*("(?:[^"\\]|\\.)*")`)
+
+// MissingKey is a message key with no translation for Locale. UsedIn is
+// the first source file that looks the key up, if any.
+type MissingKey struct {
+	Locale string
+	Key    string
+	File   string
+	UsedIn string
+}
+
+// Check reports the keys that are used in appDir or defined for the
+// default locale but missing from a locale's catalog.
+func (b *Bundle) Check(appDir string) ([]MissingKey, error) {
+	used, err := usedKeys(appDir)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]bool)
+	for key := range b.messages[b.Default] {
+		keys[key] = true
+	}
+	for key := range used {
+		keys[key] = true
+	}
+
+	var missing []MissingKey
+	for _, locale := range b.Locales {
+		for key := range keys {
+			if _, ok := b.messages[locale][key]; !ok {
+				missing = append(missing, MissingKey{
+					Locale: locale,
+					Key:    key,
+					File:   filepath.Join(appDir, filepath.FromSlash(b.files[locale])),
+					UsedIn: used[key],
+				})
+			}
+		}
+	}
+
+	sort.Slice(missing, func(i, j int) bool {
+		if missing[i].Locale != missing[j].Locale {
+			return missing[i].Locale < missing[j].Locale
+		}
+		return missing[i].Key < missing[j].Key
+	})
+	return missing, nil
+}
+
+func usedKeys(appDir string) (map[string]string, error) {
+	used := make(map[string]string)
+
+	err := filepath.Walk(appDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if info.Name() == "node_modules" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		name := info.Name()
+		generated := strings.HasSuffix(name, "_templ.go") || strings.HasSuffix(name, "_test.go")
+		if !strings.HasSuffix(name, ".templ") && (!strings.HasSuffix(name, ".go") || generated) {
+			return nil
+		}
+
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		for _, m := range translateCall.FindAllSubmatch(src, -1) {
+			key, err := strconv.Unquote(string(m[1]))
+			if err != nil {
+				continue
+			}
+			if _, ok := used[key]; !ok {
+				used[key] = path
+			}
+		}
+		return nil
+	})
+	if os.IsNotExist(err) {
+		return used, nil
+	}
+	return used, err
+}
@@ -0,0 +1,289 @@
+package i18n
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/brattlof/zeptor/internal/app/config"
+	"github.com/brattlof/zeptor/internal/app/router"
+)
+
+var localeTag = regexp.MustCompile(`^[A-Za-z]{2,3}(?:-[A-Za-z0-9]{2,8})*$`)
+
+// Bundle holds the configured locales and their message catalogs.
+type Bundle struct {
+	Locales       []string
+	Default       string
+	PrefixDefault bool
+	Detect        bool
+	Cookie        string
+	// Dir is where catalogs are read from in the file system given to New.
+	Dir string
+
+	messages map[string]map[string]string
+	files    map[string]string
+}
+
+// New loads the catalogs for cfg's locales from cfg.MessagesDir in fsys,
+// the app directory or the files beside a route manifest, as given by the
+// router's FS. A locale without a catalog has no messages of its own.
+func New(cfg config.I18nConfig, fsys fs.FS) (*Bundle, error) {
+	if len(cfg.Locales) == 0 {
+		return nil, fmt.Errorf("i18n: no locales configured")
+	}
+
+	b := &Bundle{
+		Locales:       cfg.Locales,
+		Default:       cfg.DefaultLocale,
+		PrefixDefault: cfg.PrefixDefault,
+		Detect:        cfg.Detect,
+		Cookie:        cfg.Cookie,
+		Dir:           filepath.ToSlash(filepath.Clean(cfg.MessagesDir)),
+		messages:      make(map[string]map[string]string),
+		files:         make(map[string]string),
+	}
+	if b.Default == "" {
+		b.Default = cfg.Locales[0]
+	}
+
+	for _, locale := range b.Locales {
+		if !localeTag.MatchString(locale) {
+			return nil, fmt.Errorf("i18n: invalid locale %q", locale)
+		}
+		if err := b.load(fsys, locale); err != nil {
+			return nil, err
+		}
+	}
+	if b.match(b.Default) == "" {
+		return nil, fmt.Errorf("i18n: default locale %q is not one of %v", b.Default, b.Locales)
+	}
+
+	return b, nil
+}
+
+func (b *Bundle) load(fsys fs.FS, locale string) error {
+	b.messages[locale] = make(map[string]string)
+	b.files[locale] = filepath.ToSlash(filepath.Join(b.Dir, locale+".yaml"))
+	if fsys == nil {
+		return nil
+	}
+
+	for _, ext := range []string{".yaml", ".yml", ".json"} {
+		file := filepath.ToSlash(filepath.Join(b.Dir, locale+ext))
+		src, err := fs.ReadFile(fsys, file)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		// JSON catalogs are valid YAML.
+		var tree map[string]any
+		if err := yaml.Unmarshal(src, &tree); err != nil {
+			return fmt.Errorf("parse %s: %w", file, err)
+		}
+		flatten(b.messages[locale], "", tree)
+		b.files[locale] = file
+		return nil
+	}
+	return nil
+}
+
+// flatten stores nested catalog keys joined with dots, e.g. home.title.
+func flatten(dst map[string]string, prefix string, tree map[string]any) {
+	for k, v := range tree {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		switch v := v.(type) {
+		case map[string]any:
+			flatten(dst, key, v)
+		case string:
+			dst[key] = v
+		case nil:
+		default:
+			dst[key] = fmt.Sprint(v)
+		}
+	}
+}
+
+// match returns the configured spelling of locale, or "".
+func (b *Bundle) match(locale string) string {
+	for _, l := range b.Locales {
+		if strings.EqualFold(l, locale) {
+			return l
+		}
+	}
+	return ""
+}
+
+// Split removes a leading locale segment from path, returning the locale
+// and the rest of the path, or "" and path unchanged.
+func (b *Bundle) Split(path string) (string, string) {
+	seg, rest, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+	locale := b.match(seg)
+	if locale == "" {
+		return "", path
+	}
+	return locale, "/" + rest
+}
+
+// Shadowed returns the routes of rt and its hosts whose first segment is
+// a locale. Split takes that segment as the locale, so they are only
+// reached with a second locale segment in front.
+func (b *Bundle) Shadowed(rt *router.Router) []*router.Route {
+	routers := []*router.Router{rt}
+	for _, h := range rt.Hosts() {
+		if h.Router != nil {
+			routers = append(routers, h.Router)
+		}
+	}
+
+	var shadowed []*router.Route
+	for _, rt := range routers {
+		for _, route := range rt.Routes() {
+			seg, _, _ := strings.Cut(strings.TrimPrefix(route.Pattern, "/"), "/")
+			if b.match(seg) != "" {
+				shadowed = append(shadowed, route)
+			}
+		}
+	}
+	return shadowed
+}
+
+// Path prefixes path with locale unless it is the default locale and
+// PrefixDefault is off.
+func (b *Bundle) Path(locale, path string) string {
+	if locale == b.Default && !b.PrefixDefault {
+		return path
+	}
+	if path == "/" {
+		return "/" + locale
+	}
+	return "/" + locale + path
+}
+
+// DetectLocale picks the locale for a request without one in its path,
+// from the locale cookie, then Accept-Language, then the default.
+func (b *Bundle) DetectLocale(r *http.Request) string {
+	if !b.Detect {
+		return b.Default
+	}
+	if b.Cookie != "" {
+		if c, err := r.Cookie(b.Cookie); err == nil {
+			if locale := b.match(c.Value); locale != "" {
+				return locale
+			}
+		}
+	}
+	for _, tag := range acceptLanguages(r.Header.Get("Accept-Language")) {
+		if locale := b.match(tag); locale != "" {
+			return locale
+		}
+		base, _, _ := strings.Cut(tag, "-")
+		for _, l := range b.Locales {
+			if lb, _, _ := strings.Cut(l, "-"); strings.EqualFold(lb, base) {
+				return l
+			}
+		}
+	}
+	return b.Default
+}
+
+// acceptLanguages returns the language tags in header, most preferred
+// first.
+func acceptLanguages(header string) []string {
+	type tag struct {
+		name string
+		q    float64
+	}
+
+	var tags []tag
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if name == "" || name == "*" {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+		if q > 0 {
+			tags = append(tags, tag{name, q})
+		}
+	}
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+
+	names := make([]string, len(tags))
+	for i, t := range tags {
+		names[i] = t.name
+	}
+	return names
+}
+
+// Message returns the message for key in locale, falling back to the
+// default locale.
+func (b *Bundle) Message(locale, key string) (string, bool) {
+	if msg, ok := b.messages[locale][key]; ok {
+		return msg, true
+	}
+	msg, ok := b.messages[b.Default][key]
+	return msg, ok
+}
+
+type localeKey struct{}
+
+type active struct {
+	bundle *Bundle
+	locale string
+}
+
+func WithLocale(ctx context.Context, b *Bundle, locale string) context.Context {
+	return context.WithValue(ctx, localeKey{}, active{b, locale})
+}
+
+// Locale returns the request's locale, or "" when i18n is not configured.
+func Locale(ctx context.Context) string {
+	a, _ := ctx.Value(localeKey{}).(active)
+	return a.locale
+}
+
+// T translates key into the request's locale, formatting args into the
+// message with fmt verbs. Unknown keys are returned as is.
+func T(ctx context.Context, key string, args ...any) string {
+	a, _ := ctx.Value(localeKey{}).(active)
+	if a.bundle == nil {
+		return key
+	}
+	msg, ok := a.bundle.Message(a.locale, key)
+	if !ok {
+		return key
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(msg, args...)
+	}
+	return msg
+}
+
+// Path returns path for the request's locale, e.g. /fr/about.
+func Path(ctx context.Context, path string) string {
+	a, _ := ctx.Value(localeKey{}).(active)
+	if a.bundle == nil {
+		return path
+	}
+	return a.bundle.Path(a.locale, path)
+}
@@ -0,0 +1,196 @@
+package i18n
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/brattlof/zeptor/internal/app/config"
+	"github.com/brattlof/zeptor/internal/app/router"
+)
+
+func newBundle(t *testing.T) (*Bundle, string) {
+	t.Helper()
+	appDir := t.TempDir()
+	files := map[string]string{
+		"locales/en.yaml": "nav:\n  home: Home\n  about: About\ngreeting: Hello, %s\n",
+		"locales/fr.json": `{"nav": {"home": "Accueil"}, "greeting": "Bonjour, %s"}`,
+		"page.templ":      "package app\n\ntempl Page() {\n\t<h1>{ i18n.T(ctx, \"page.title\") }</h1>\n}\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(appDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	b, err := New(config.I18nConfig{
+		Locales:     []string{"en", "fr", "pt-BR"},
+		Detect:      true,
+		Cookie:      "locale",
+		MessagesDir: "locales",
+	}, os.DirFS(appDir))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return b, appDir
+}
+
+func TestBundle_Split(t *testing.T) {
+	b, _ := newBundle(t)
+
+	tests := []struct {
+		path       string
+		wantLocale string
+		wantPath   string
+	}{
+		{"/fr/about", "fr", "/about"},
+		{"/fr", "fr", "/"},
+		{"/pt-br/blog/x", "pt-BR", "/blog/x"},
+		{"/about", "", "/about"},
+		{"/", "", "/"},
+		{"/french", "", "/french"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			locale, path := b.Split(tt.path)
+			if locale != tt.wantLocale || path != tt.wantPath {
+				t.Errorf("Split() = %q, %q, want %q, %q", locale, path, tt.wantLocale, tt.wantPath)
+			}
+		})
+	}
+}
+
+func TestBundle_DetectLocale(t *testing.T) {
+	b, _ := newBundle(t)
+
+	tests := []struct {
+		name   string
+		accept string
+		cookie string
+		want   string
+	}{
+		{"default", "", "", "en"},
+		{"exact", "fr", "", "fr"},
+		{"quality", "de;q=0.9, fr;q=0.5, en;q=0.1", "", "fr"},
+		{"base language", "fr-CA", "", "fr"},
+		{"region", "pt-BR,pt;q=0.8", "", "pt-BR"},
+		{"cookie wins", "fr", "en", "en"},
+		{"unknown cookie", "fr", "xx", "fr"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept-Language", tt.accept)
+			}
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: "locale", Value: tt.cookie})
+			}
+			if got := b.DetectLocale(req); got != tt.want {
+				t.Errorf("DetectLocale() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestT(t *testing.T) {
+	b, _ := newBundle(t)
+	ctx := WithLocale(context.Background(), b, "fr")
+
+	if got := T(ctx, "nav.home"); got != "Accueil" {
+		t.Errorf("T(nav.home) = %q, want Accueil", got)
+	}
+	if got := T(ctx, "nav.about"); got != "About" {
+		t.Errorf("T(nav.about) = %q, want the default locale's About", got)
+	}
+	if got := T(ctx, "greeting", "Ada"); got != "Bonjour, Ada" {
+		t.Errorf("T(greeting) = %q, want Bonjour, Ada", got)
+	}
+	if got := T(ctx, "missing.key"); got != "missing.key" {
+		t.Errorf("T(missing.key) = %q, want the key", got)
+	}
+	if got := T(context.Background(), "nav.home"); got != "nav.home" {
+		t.Errorf("T() without a locale = %q, want the key", got)
+	}
+
+	if got := Path(ctx, "/about"); got != "/fr/about" {
+		t.Errorf("Path() = %q, want /fr/about", got)
+	}
+	if got := Path(WithLocale(context.Background(), b, "en"), "/about"); got != "/about" {
+		t.Errorf("Path() for the default locale = %q, want /about", got)
+	}
+}
+
+func TestBundle_Check(t *testing.T) {
+	b, appDir := newBundle(t)
+
+	missing, err := b.Check(appDir)
+	if err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+
+	got := make(map[string]bool)
+	for _, m := range missing {
+		got[m.Locale+" "+m.Key] = true
+	}
+	for _, want := range []string{
+		"en page.title",
+		"fr nav.about",
+		"fr page.title",
+		"pt-BR greeting",
+		"pt-BR nav.home",
+	} {
+		if !got[want] {
+			t.Errorf("Check() missing %q in %v", want, missing)
+		}
+	}
+	if got["fr nav.home"] {
+		t.Error("Check() reported fr nav.home, which is translated")
+	}
+}
+
+func TestNew_FS(t *testing.T) {
+	b, err := New(config.I18nConfig{Locales: []string{"de"}, MessagesDir: "locales"}, fstest.MapFS{
+		"locales/de.yaml": {Data: []byte("nav:\n  home: Startseite\n")},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if got := T(WithLocale(context.Background(), b, "de"), "nav.home"); got != "Startseite" {
+		t.Errorf("T(nav.home) = %q, want Startseite", got)
+	}
+}
+
+func TestNew_InvalidDefault(t *testing.T) {
+	_, err := New(config.I18nConfig{Locales: []string{"en"}, DefaultLocale: "de"}, nil)
+	if err == nil {
+		t.Error("New() error = nil, want error for a default locale that is not configured")
+	}
+}
+
+func TestBundle_Shadowed(t *testing.T) {
+	b, _ := newBundle(t)
+	rt, err := router.NewFS(fstest.MapFS{
+		"fr/page.templ":    {Data: []byte("package fr\n")},
+		"about/page.templ": {Data: []byte("package about\n")},
+		"slug_/page.templ": {Data: []byte("package slug_\n")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	shadowed := b.Shadowed(rt)
+	if len(shadowed) != 1 || shadowed[0].Pattern != "/fr" {
+		t.Errorf("Shadowed() = %v, want [/fr]", shadowed)
+	}
+}
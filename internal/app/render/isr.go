@@ -0,0 +1,309 @@
+package render
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// CacheStatus tells how the ISR cache answered a Get.
+type CacheStatus string
+
+const (
+	CacheHit   CacheStatus = "HIT"
+	CacheStale CacheStatus = "STALE"
+	CacheMiss  CacheStatus = "MISS"
+)
+
+// CacheKey identifies a cached page. Path is the page's path without a
+// locale prefix. Host is only set when the app is served on more than one
+// host, Locale only when it has locales.
+type CacheKey struct {
+	Host   string
+	Locale string
+	Path   string
+}
+
+// RenderFunc renders a page for the ISR cache.
+type RenderFunc func(ctx context.Context) ([]byte, error)
+
+// ISRStats counts how the cache answered. Regenerations include the first
+// render of each page; the durations cover successful and failed renders.
+type ISRStats struct {
+	Entries          int           `json:"entries"`
+	Hits             uint64        `json:"hits"`
+	StaleHits        uint64        `json:"staleHits"`
+	Misses           uint64        `json:"misses"`
+	Regenerations    uint64        `json:"regenerations"`
+	Errors           uint64        `json:"errors"`
+	Purged           uint64        `json:"purged"`
+	Evicted          uint64        `json:"evicted"`
+	LastRegeneration time.Duration `json:"lastRegeneration"`
+	AvgRegeneration  time.Duration `json:"avgRegeneration"`
+	MaxRegeneration  time.Duration `json:"maxRegeneration"`
+}
+
+// ISRCache stores rendered pages for incremental static regeneration. A
+// page is rendered on its first request and served from the cache after
+// that. Once older than its revalidate interval it is still served, stale,
+// while a single background render per key replaces it. Past its maximum
+// number of entries, the least recently used page is dropped.
+type ISRCache struct {
+	// OnError is called when a background render fails. The stale page
+	// stays cached and the next request past its interval tries again.
+	OnError func(key CacheKey, err error)
+
+	revalidate time.Duration
+	maxEntries int
+	now        func() time.Time
+
+	mu      sync.Mutex
+	entries map[CacheKey]*list.Element
+	// lru orders the entries from most to least recently used.
+	lru      *list.List
+	inflight map[CacheKey]*isrCall
+	stats    ISRStats
+	total    time.Duration
+	// epoch counts purges, so that a render that started before one is
+	// not cached after it.
+	epoch uint64
+}
+
+type isrEntry struct {
+	key        CacheKey
+	body       []byte
+	tags       []string
+	renderedAt time.Time
+	revalidate time.Duration
+}
+
+type isrCall struct {
+	done  chan struct{}
+	epoch uint64
+	body  []byte
+	err   error
+}
+
+type cacheTagsKey struct{}
+
+type cacheTags struct {
+	mu   sync.Mutex
+	tags []string
+}
+
+var defaultCache atomic.Pointer[ISRCache]
+
+// NewISRCache returns a cache whose pages are revalidated every
+// revalidate, unless Get is given an interval of its own, and which holds
+// at most maxEntries pages. A maxEntries of 0 leaves it unbounded.
+func NewISRCache(revalidate time.Duration, maxEntries int) *ISRCache {
+	return &ISRCache{
+		revalidate: revalidate,
+		maxEntries: maxEntries,
+		now:        time.Now,
+		entries:    make(map[CacheKey]*list.Element),
+		lru:        list.New(),
+		inflight:   make(map[CacheKey]*isrCall),
+	}
+}
+
+// Get returns the page cached at key, rendering it with fn on a miss.
+// Concurrent misses for a key share one render. A stale page is returned
+// at once and re-rendered in the background, with ctx's values but not
+// its cancellation. A zero revalidate uses the cache's interval.
+func (c *ISRCache) Get(ctx context.Context, key CacheKey, revalidate time.Duration, fn RenderFunc) ([]byte, CacheStatus, error) {
+	if revalidate <= 0 {
+		revalidate = c.revalidate
+	}
+
+	c.mu.Lock()
+	if el, ok := c.entries[key]; ok {
+		c.lru.MoveToFront(el)
+		e := el.Value.(*isrEntry)
+		if c.now().Sub(e.renderedAt) < e.revalidate {
+			c.stats.Hits++
+			c.mu.Unlock()
+			return e.body, CacheHit, nil
+		}
+
+		c.stats.StaleHits++
+		if _, busy := c.inflight[key]; !busy {
+			call := c.begin(key)
+			go c.run(context.WithoutCancel(ctx), key, revalidate, fn, call, true)
+		}
+		c.mu.Unlock()
+		return e.body, CacheStale, nil
+	}
+
+	c.stats.Misses++
+	call, busy := c.inflight[key]
+	if !busy {
+		call = c.begin(key)
+	}
+	c.mu.Unlock()
+
+	if !busy {
+		c.run(ctx, key, revalidate, fn, call, false)
+	}
+
+	select {
+	case <-call.done:
+		return call.body, CacheMiss, call.err
+	case <-ctx.Done():
+		return nil, CacheMiss, ctx.Err()
+	}
+}
+
+// begin records an in-flight render of key. c.mu must be held.
+func (c *ISRCache) begin(key CacheKey) *isrCall {
+	call := &isrCall{done: make(chan struct{}), epoch: c.epoch}
+	c.inflight[key] = call
+	return call
+}
+
+func (c *ISRCache) run(ctx context.Context, key CacheKey, revalidate time.Duration, fn RenderFunc, call *isrCall, background bool) {
+	tags := &cacheTags{}
+	ctx = context.WithValue(ctx, cacheTagsKey{}, tags)
+
+	start := time.Now()
+	body, err := func() (body []byte, err error) {
+		defer func() {
+			if v := recover(); v != nil {
+				err = fmt.Errorf("panic: %v", v)
+			}
+		}()
+		return fn(ctx)
+	}()
+	elapsed := time.Since(start)
+
+	c.mu.Lock()
+	delete(c.inflight, key)
+	if err == nil && call.epoch == c.epoch {
+		c.store(&isrEntry{key: key, body: body, tags: tags.tags, renderedAt: c.now(), revalidate: revalidate})
+	} else if err != nil {
+		c.stats.Errors++
+	}
+	c.stats.Regenerations++
+	c.stats.LastRegeneration = elapsed
+	c.stats.MaxRegeneration = max(c.stats.MaxRegeneration, elapsed)
+	c.total += elapsed
+	c.mu.Unlock()
+
+	call.body, call.err = body, err
+	close(call.done)
+
+	if err != nil && background && c.OnError != nil {
+		c.OnError(key, err)
+	}
+}
+
+// store caches e as the most recently used page, dropping the least
+// recently used ones past maxEntries. c.mu must be held.
+func (c *ISRCache) store(e *isrEntry) {
+	if el, ok := c.entries[e.key]; ok {
+		el.Value = e
+		c.lru.MoveToFront(el)
+		return
+	}
+	c.entries[e.key] = c.lru.PushFront(e)
+
+	for c.maxEntries > 0 && c.lru.Len() > c.maxEntries {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*isrEntry).key)
+		c.stats.Evicted++
+	}
+}
+
+// Revalidate purges the pages cached for path, in every locale and on
+// every host, and returns how many there were. The next request renders
+// them again.
+func (c *ISRCache) Revalidate(path string) int {
+	return c.purge(func(key CacheKey, e *isrEntry) bool {
+		return key.Path == path
+	})
+}
+
+// RevalidateTag purges the pages tagged with tag and returns how many
+// there were.
+func (c *ISRCache) RevalidateTag(tag string) int {
+	return c.purge(func(key CacheKey, e *isrEntry) bool {
+		return slices.Contains(e.tags, tag)
+	})
+}
+
+func (c *ISRCache) purge(match func(CacheKey, *isrEntry) bool) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.epoch++
+	n := 0
+	for key, el := range c.entries {
+		if match(key, el.Value.(*isrEntry)) {
+			c.lru.Remove(el)
+			delete(c.entries, key)
+			n++
+		}
+	}
+	c.stats.Purged += uint64(n)
+	return n
+}
+
+func (c *ISRCache) Stats() ISRStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.stats
+	stats.Entries = len(c.entries)
+	if stats.Regenerations > 0 {
+		stats.AvgRegeneration = c.total / time.Duration(stats.Regenerations)
+	}
+	return stats
+}
+
+// CacheTag tags the page being rendered into the ISR cache, so that
+// RevalidateTag purges it, e.g. render.CacheTag(ctx, "post:"+slug). It does
+// nothing outside a cached render.
+func CacheTag(ctx context.Context, tags ...string) {
+	set, ok := ctx.Value(cacheTagsKey{}).(*cacheTags)
+	if !ok {
+		return
+	}
+
+	set.mu.Lock()
+	defer set.mu.Unlock()
+	for _, tag := range tags {
+		if !slices.Contains(set.tags, tag) {
+			set.tags = append(set.tags, tag)
+		}
+	}
+}
+
+// SetDefaultCache makes c the cache Revalidate and RevalidateTag purge.
+// server.New sets the server's own, whatever its render mode, since routes
+// can opt into ISR on their own.
+func SetDefaultCache(c *ISRCache) {
+	defaultCache.Store(c)
+}
+
+// Revalidate purges path from the default ISR cache, e.g. from an API
+// route after a mutation, and returns the number of pages purged.
+func Revalidate(path string) int {
+	if c := defaultCache.Load(); c != nil {
+		return c.Revalidate(path)
+	}
+	return 0
+}
+
+// RevalidateTag purges the pages tagged with tag from the default ISR
+// cache and returns the number of pages purged.
+func RevalidateTag(tag string) int {
+	if c := defaultCache.Load(); c != nil {
+		return c.RevalidateTag(tag)
+	}
+	return 0
+}
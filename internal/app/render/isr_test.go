@@ -0,0 +1,227 @@
+package render
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestISRCache(t *testing.T) {
+	c := NewISRCache(time.Minute, 0)
+	now := time.Now()
+	c.now = func() time.Time { return now }
+
+	key := CacheKey{Path: "/blog"}
+	var renders atomic.Int32
+	render := func(ctx context.Context) ([]byte, error) {
+		return []byte(fmt.Sprintf("v%d", renders.Add(1))), nil
+	}
+
+	get := func(want string, wantStatus CacheStatus) {
+		t.Helper()
+		body, status, err := c.Get(context.Background(), key, 0, render)
+		if err != nil || string(body) != want || status != wantStatus {
+			t.Fatalf("Get() = %q, %s, %v, want %q, %s", body, status, err, want, wantStatus)
+		}
+	}
+
+	get("v1", CacheMiss)
+	get("v1", CacheHit)
+
+	now = now.Add(2 * time.Minute)
+	get("v1", CacheStale)
+	waitInflight(t, c)
+	get("v2", CacheHit)
+
+	stats := c.Stats()
+	if stats.Entries != 1 || stats.Hits != 2 || stats.StaleHits != 1 || stats.Misses != 1 || stats.Regenerations != 2 {
+		t.Errorf("Stats() = %+v", stats)
+	}
+}
+
+func TestISRCache_SingleFlight(t *testing.T) {
+	c := NewISRCache(time.Minute, 0)
+	now := time.Now()
+	c.now = func() time.Time { return now }
+
+	key := CacheKey{Path: "/"}
+	release := make(chan struct{})
+	var renders atomic.Int32
+	render := func(ctx context.Context) ([]byte, error) {
+		renders.Add(1)
+		<-release
+		return []byte("page"), nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if body, _, err := c.Get(context.Background(), key, 0, render); err != nil || string(body) != "page" {
+				t.Errorf("Get() = %q, %v", body, err)
+			}
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if n := renders.Load(); n != 1 {
+		t.Fatalf("concurrent misses rendered %d times, want 1", n)
+	}
+
+	now = now.Add(2 * time.Minute)
+	block := make(chan struct{})
+	slow := func(ctx context.Context) ([]byte, error) {
+		renders.Add(1)
+		<-block
+		return []byte("new"), nil
+	}
+	for i := 0; i < 20; i++ {
+		if _, status, _ := c.Get(context.Background(), key, 0, slow); status != CacheStale {
+			t.Fatalf("Get() status = %s, want STALE", status)
+		}
+	}
+	close(block)
+	waitInflight(t, c)
+	if n := renders.Load(); n != 2 {
+		t.Errorf("expired page rendered %d more times, want 1", n-1)
+	}
+}
+
+func TestISRCache_Errors(t *testing.T) {
+	c := NewISRCache(time.Minute, 0)
+	now := time.Now()
+	c.now = func() time.Time { return now }
+
+	key := CacheKey{Path: "/"}
+	boom := errors.New("boom")
+	failed := make(chan error, 1)
+	c.OnError = func(k CacheKey, err error) { failed <- err }
+
+	if _, _, err := c.Get(context.Background(), key, 0, func(ctx context.Context) ([]byte, error) {
+		return nil, boom
+	}); !errors.Is(err, boom) {
+		t.Fatalf("Get() error = %v, want boom", err)
+	}
+
+	c.Get(context.Background(), key, 0, func(ctx context.Context) ([]byte, error) {
+		return []byte("ok"), nil
+	})
+	now = now.Add(2 * time.Minute)
+	body, status, _ := c.Get(context.Background(), key, 0, func(ctx context.Context) ([]byte, error) {
+		panic("render panic")
+	})
+	if string(body) != "ok" || status != CacheStale {
+		t.Errorf("Get() = %q, %s, want the stale page", body, status)
+	}
+	if err := <-failed; err == nil {
+		t.Error("OnError not called for the failed regeneration")
+	}
+	waitInflight(t, c)
+
+	if stats := c.Stats(); stats.Errors != 2 || stats.Entries != 1 {
+		t.Errorf("Errors, Entries = %d, %d, want 2, 1", stats.Errors, stats.Entries)
+	}
+}
+
+func waitInflight(t *testing.T, c *ISRCache) {
+	t.Helper()
+	for i := 0; i < 100; i++ {
+		c.mu.Lock()
+		n := len(c.inflight)
+		c.mu.Unlock()
+		if n == 0 {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("regeneration did not finish")
+}
+
+func TestISRCache_Revalidate(t *testing.T) {
+	c := NewISRCache(time.Minute, 0)
+	ctx := context.Background()
+
+	page := func(tags ...string) RenderFunc {
+		return func(ctx context.Context) ([]byte, error) {
+			CacheTag(ctx, tags...)
+			return []byte("page"), nil
+		}
+	}
+	c.Get(ctx, CacheKey{Locale: "en", Path: "/blog/hello"}, 0, page("blog", "post:hello"))
+	c.Get(ctx, CacheKey{Locale: "de", Path: "/blog/hello"}, 0, page("blog", "post:hello"))
+	c.Get(ctx, CacheKey{Locale: "en", Path: "/blog/other"}, 0, page("blog", "post:other"))
+	c.Get(ctx, CacheKey{Locale: "en", Path: "/about"}, 0, page())
+
+	if n := c.Revalidate("/blog/hello"); n != 2 {
+		t.Errorf("Revalidate(/blog/hello) = %d, want 2", n)
+	}
+	if n := c.RevalidateTag("post:hello"); n != 0 {
+		t.Errorf("RevalidateTag(post:hello) after purging its path = %d, want 0", n)
+	}
+	if n := c.RevalidateTag("blog"); n != 1 {
+		t.Errorf("RevalidateTag(blog) = %d, want 1", n)
+	}
+	if _, status, _ := c.Get(ctx, CacheKey{Locale: "en", Path: "/about"}, 0, page()); status != CacheHit {
+		t.Errorf("/about status = %s, want HIT", status)
+	}
+
+	// A render that started before a purge must not cache what it read.
+	release := make(chan struct{})
+	done := make(chan struct{})
+	key := CacheKey{Path: "/slow"}
+	go func() {
+		defer close(done)
+		c.Get(ctx, key, 0, func(ctx context.Context) ([]byte, error) {
+			<-release
+			return []byte("old"), nil
+		})
+	}()
+	for {
+		c.mu.Lock()
+		_, busy := c.inflight[key]
+		c.mu.Unlock()
+		if busy {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	c.Revalidate("/slow")
+	close(release)
+	<-done
+
+	if _, status, _ := c.Get(ctx, key, 0, page()); status != CacheMiss {
+		t.Errorf("page rendered before a purge was cached, status = %s", status)
+	}
+	if stats := c.Stats(); stats.Purged != 3 {
+		t.Errorf("Stats().Purged = %d, want 3", stats.Purged)
+	}
+}
+
+func TestISRCache_Evict(t *testing.T) {
+	c := NewISRCache(time.Minute, 2)
+	ctx := context.Background()
+	page := func(ctx context.Context) ([]byte, error) { return []byte("page"), nil }
+
+	c.Get(ctx, CacheKey{Path: "/a"}, 0, page)
+	c.Get(ctx, CacheKey{Path: "/b"}, 0, page)
+	c.Get(ctx, CacheKey{Path: "/a"}, 0, page)
+	c.Get(ctx, CacheKey{Path: "/c"}, 0, page)
+
+	for _, tt := range []struct {
+		path string
+		want CacheStatus
+	}{{"/a", CacheHit}, {"/c", CacheHit}, {"/b", CacheMiss}} {
+		if _, status, _ := c.Get(ctx, CacheKey{Path: tt.path}, 0, page); status != tt.want {
+			t.Errorf("%s status = %s, want %s", tt.path, status, tt.want)
+		}
+	}
+	if stats := c.Stats(); stats.Entries != 2 || stats.Evicted != 2 {
+		t.Errorf("Stats() = %+v, want 2 entries and 2 evictions", stats)
+	}
+}
@@ -0,0 +1,24 @@
+package render
+
+import (
+	"context"
+	"io"
+
+	"github.com/a-h/templ"
+)
+
+// WithLayouts wraps page in layouts, outermost first. Each layout receives
+// the next component as its templ children.
+func WithLayouts(page Component, layouts ...Component) Component {
+	component := page
+	for i := len(layouts) - 1; i >= 0; i-- {
+		component = withChild(layouts[i], component)
+	}
+	return component
+}
+
+func withChild(layout, child Component) Component {
+	return templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+		return layout.Render(templ.WithChildren(ctx, child), w)
+	})
+}
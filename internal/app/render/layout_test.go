@@ -0,0 +1,37 @@
+package render
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/a-h/templ"
+)
+
+func wrap(name string) Component {
+	return templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+		io.WriteString(w, "<"+name+">")
+		if err := templ.GetChildren(ctx).Render(ctx, w); err != nil {
+			return err
+		}
+		_, err := io.WriteString(w, "</"+name+">")
+		return err
+	})
+}
+
+func TestWithLayouts(t *testing.T) {
+	page := templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+		_, err := io.WriteString(w, "page")
+		return err
+	})
+
+	var buf bytes.Buffer
+	if err := WithLayouts(page, wrap("root"), wrap("blog")).Render(context.Background(), &buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := buf.String(), "<root><blog>page</blog></root>"; got != want {
+		t.Errorf("WithLayouts() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,122 @@
+package render
+
+import (
+	"context"
+	"html"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/a-h/templ"
+)
+
+// Metadata describes a page's <head>. It is declared per directory, in a
+// meta.yaml or as Metadata in page.go or layout.go, and merged from the
+// root down.
+type Metadata struct {
+	Title         string    `yaml:"title" json:"title,omitempty"`
+	TitleTemplate string    `yaml:"titleTemplate" json:"titleTemplate,omitempty"`
+	Description   string    `yaml:"description" json:"description,omitempty"`
+	Canonical     string    `yaml:"canonical" json:"canonical,omitempty"`
+	Robots        string    `yaml:"robots" json:"robots,omitempty"`
+	CacheControl  string    `yaml:"cacheControl" json:"cacheControl,omitempty"`
+	Render        string    `yaml:"render" json:"render,omitempty"`
+	Revalidate    int       `yaml:"revalidate" json:"revalidate,omitempty"`
+	Tags          []string  `yaml:"tags" json:"tags,omitempty"`
+	OpenGraph     OpenGraph `yaml:"openGraph" json:"openGraph,omitzero"`
+}
+
+type OpenGraph struct {
+	Title       string `yaml:"title" json:"title,omitempty"`
+	Description string `yaml:"description" json:"description,omitempty"`
+	Image       string `yaml:"image" json:"image,omitempty"`
+	URL         string `yaml:"url" json:"url,omitempty"`
+	Type        string `yaml:"type" json:"type,omitempty"`
+}
+
+// Merge returns m overridden by the non-empty fields of child. A title
+// template set above child is applied to child's title, e.g. "%s | Site".
+func (m Metadata) Merge(child Metadata) Metadata {
+	if child.Title != "" {
+		m.Title = child.Title
+		if m.TitleTemplate != "" {
+			m.Title = strings.Replace(m.TitleTemplate, "%s", child.Title, 1)
+		}
+	}
+	if child.TitleTemplate != "" {
+		m.TitleTemplate = child.TitleTemplate
+	}
+	m.Description = override(m.Description, child.Description)
+	m.Canonical = override(m.Canonical, child.Canonical)
+	m.Robots = override(m.Robots, child.Robots)
+	m.CacheControl = override(m.CacheControl, child.CacheControl)
+	m.Render = override(m.Render, child.Render)
+	if child.Revalidate != 0 {
+		m.Revalidate = child.Revalidate
+	}
+	for _, tag := range child.Tags {
+		if !slices.Contains(m.Tags, tag) {
+			m.Tags = append(slices.Clip(m.Tags), tag)
+		}
+	}
+
+	m.OpenGraph.Title = override(m.OpenGraph.Title, child.OpenGraph.Title)
+	m.OpenGraph.Description = override(m.OpenGraph.Description, child.OpenGraph.Description)
+	m.OpenGraph.Image = override(m.OpenGraph.Image, child.OpenGraph.Image)
+	m.OpenGraph.URL = override(m.OpenGraph.URL, child.OpenGraph.URL)
+	m.OpenGraph.Type = override(m.OpenGraph.Type, child.OpenGraph.Type)
+	return m
+}
+
+func override(parent, child string) string {
+	if child != "" {
+		return child
+	}
+	return parent
+}
+
+type metadataKey struct{}
+
+func WithMetadata(ctx context.Context, m Metadata) context.Context {
+	return context.WithValue(ctx, metadataKey{}, m)
+}
+
+func MetadataFrom(ctx context.Context) Metadata {
+	m, _ := ctx.Value(metadataKey{}).(Metadata)
+	return m
+}
+
+// Head renders the request's metadata as <head> elements. Use it inside a
+// layout's <head>: @render.Head().
+func Head() templ.Component {
+	return templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+		m := MetadataFrom(ctx)
+
+		var b strings.Builder
+		if m.Title != "" {
+			b.WriteString("<title>" + html.EscapeString(m.Title) + "</title>")
+		}
+		writeMeta(&b, "name", "description", m.Description)
+		writeMeta(&b, "name", "robots", m.Robots)
+		if m.Canonical != "" {
+			b.WriteString(`<link rel="canonical" href="` + html.EscapeString(m.Canonical) + `">`)
+		}
+
+		og := m.OpenGraph
+		writeMeta(&b, "property", "og:title", override(m.Title, og.Title))
+		writeMeta(&b, "property", "og:description", override(m.Description, og.Description))
+		writeMeta(&b, "property", "og:image", og.Image)
+		writeMeta(&b, "property", "og:url", override(m.Canonical, og.URL))
+		writeMeta(&b, "property", "og:type", og.Type)
+
+		_, err := io.WriteString(w, b.String())
+		return err
+	})
+}
+
+func writeMeta(b *strings.Builder, attr, name, content string) {
+	if content == "" {
+		return
+	}
+	b.WriteString(`<meta ` + attr + `="` + name + `" content="` + html.EscapeString(content) + `">`)
+}
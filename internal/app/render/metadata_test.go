@@ -0,0 +1,54 @@
+package render
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestMetadata_Merge(t *testing.T) {
+	root := Metadata{Title: "Zeptor", TitleTemplate: "%s | Zeptor", Robots: "index"}
+	blog := Metadata{Title: "Blog", Description: "Posts", TitleTemplate: "%s - Blog", Tags: []string{"blog"}}
+	post := Metadata{Title: "Hello", OpenGraph: OpenGraph{Image: "/hello.png"}, Tags: []string{"post", "blog"}}
+
+	got := root.Merge(blog).Merge(post)
+	want := Metadata{
+		Title:         "Hello - Blog",
+		TitleTemplate: "%s - Blog",
+		Description:   "Posts",
+		Robots:        "index",
+		OpenGraph:     OpenGraph{Image: "/hello.png"},
+		Tags:          []string{"blog", "post"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Merge() = %+v, want %+v", got, want)
+	}
+
+	if got := root.Merge(blog).Title; got != "Blog | Zeptor" {
+		t.Errorf("Merge().Title = %q, want %q", got, "Blog | Zeptor")
+	}
+}
+
+func TestHead(t *testing.T) {
+	ctx := WithMetadata(context.Background(), Metadata{
+		Title:       "Fish & Chips",
+		Description: "A <great> meal",
+		Canonical:   "https://example.com/fish",
+	})
+
+	var buf bytes.Buffer
+	if err := Head().Render(ctx, &buf); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `<title>Fish &amp; Chips</title>` +
+		`<meta name="description" content="A &lt;great&gt; meal">` +
+		`<link rel="canonical" href="https://example.com/fish">` +
+		`<meta property="og:title" content="Fish &amp; Chips">` +
+		`<meta property="og:description" content="A &lt;great&gt; meal">` +
+		`<meta property="og:url" content="https://example.com/fish">`
+	if got := buf.String(); got != want {
+		t.Errorf("Head() =\n%s\nwant\n%s", got, want)
+	}
+}
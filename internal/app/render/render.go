@@ -3,12 +3,16 @@ package render
 import (
 	"context"
 	"io"
+
+	"github.com/a-h/templ"
 )
 
 type RenderMode int
 
 const (
 	ModeSSR RenderMode = iota
+	// ModeSSG serves the pages zt build --ssg pre-rendered into
+	// build.staticDir and renders the rest per request.
 	ModeSSG
 	ModeISR
 )
@@ -29,17 +33,46 @@ func (r *Renderer) Render(ctx context.Context, w io.Writer, component Component)
 	return component.Render(ctx, w)
 }
 
+// RenderFragments renders only the templ.Fragment blocks of component
+// named by names, wherever they are in it.
+func (r *Renderer) RenderFragments(ctx context.Context, w io.Writer, component Component, names ...string) error {
+	ids := make([]any, len(names))
+	for i, name := range names {
+		ids[i] = name
+	}
+	return templ.RenderFragments(ctx, w, component, ids...)
+}
+
 func (r *Renderer) Mode() RenderMode {
 	return r.mode
 }
 
 func ParseRenderMode(s string) RenderMode {
+	mode, _ := LookupRenderMode(s)
+	return mode
+}
+
+// LookupRenderMode is ParseRenderMode that reports whether s names a mode.
+func LookupRenderMode(s string) (RenderMode, bool) {
 	switch s {
+	case "ssr":
+		return ModeSSR, true
 	case "ssg":
-		return ModeSSG
+		return ModeSSG, true
 	case "isr":
-		return ModeISR
+		return ModeISR, true
+	default:
+		return ModeSSR, false
+	}
+}
+
+func (m RenderMode) String() string {
+	switch m {
+	case ModeSSG:
+		return "ssg"
+	case ModeISR:
+		return "isr"
 	default:
-		return ModeSSR
+		return "ssr"
 	}
 }
@@ -0,0 +1,193 @@
+package render
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/a-h/templ"
+)
+
+// swapScript moves a streamed chunk into the place of its fallback. It is
+// written once, before the first chunk.
+const swapScript = `<script>function $zd(n){var c=document.getElementById("zd-c"+n),f=document.getElementById("zd-"+n);if(c&&f){f.replaceWith(c.content);c.remove()}}</script>`
+
+// Stream collects the deferred sections of a page rendered with a context
+// from WithStream, so that they can be written after the page's shell as
+// out-of-order chunks.
+type Stream struct {
+	// Fallback holds the place of a Defer without a fallback of its own,
+	// e.g. the route's loading.templ.
+	Fallback Component
+	// OnError is called when a deferred section fails. The component it
+	// returns, if any, is swapped in; otherwise the fallback stays.
+	OnError func(err error) Component
+
+	// ctx is what deferred sections render with. It is the context given
+	// to WithStream, from before any component added templ's render state.
+	ctx context.Context
+
+	mu      sync.Mutex
+	next    int
+	pending int
+	ready   []*deferred
+	notify  chan struct{}
+	script  bool
+}
+
+type deferred struct {
+	id   int
+	body []byte
+	err  error
+}
+
+type streamKey struct{}
+
+// NewStream returns an empty stream for one page.
+func NewStream() *Stream {
+	return &Stream{notify: make(chan struct{}, 1)}
+}
+
+// WithStream makes Defer components rendered with ctx stream into s.
+// Deferred sections render with ctx itself, not the context of the
+// component deferring them, so each gets templ render state of its own;
+// values they need must be set on ctx before WithStream.
+func WithStream(ctx context.Context, s *Stream) context.Context {
+	s.ctx = context.WithValue(ctx, streamKey{}, (*Stream)(nil))
+	return context.WithValue(ctx, streamKey{}, s)
+}
+
+// Defer renders content concurrently with the rest of the page. When the
+// page is streamed, fallback (or the stream's Fallback when nil) is
+// written in content's place and content follows as a chunk once ready.
+// Otherwise, as for ISR and SSG pages, content is rendered in place.
+func Defer(content, fallback Component) Component {
+	return templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+		s, _ := ctx.Value(streamKey{}).(*Stream)
+		if s == nil {
+			return content.Render(ctx, w)
+		}
+		placeholder := fallback
+		if placeholder == nil {
+			placeholder = s.Fallback
+		}
+
+		s.mu.Lock()
+		id := s.next
+		s.next++
+		s.pending++
+		s.mu.Unlock()
+
+		// Sections deferred within content are rendered in place, since
+		// their chunks could otherwise arrive before their fallback.
+		go s.render(templ.WithNonce(s.ctx, templ.GetNonce(ctx)), id, content)
+
+		if _, err := fmt.Fprintf(w, `<zeptor-deferred id="zd-%d" style="display:contents">`, id); err != nil {
+			return err
+		}
+		if placeholder != nil {
+			if err := placeholder.Render(ctx, w); err != nil {
+				return err
+			}
+		}
+		_, err := io.WriteString(w, `</zeptor-deferred>`)
+		return err
+	})
+}
+
+func (s *Stream) render(ctx context.Context, id int, content Component) {
+	d := &deferred{id: id}
+	var buf bytes.Buffer
+	d.err = func() (err error) {
+		defer func() {
+			if v := recover(); v != nil {
+				err = fmt.Errorf("panic: %v", v)
+			}
+		}()
+		return content.Render(ctx, &buf)
+	}()
+	if d.err == nil {
+		d.body = buf.Bytes()
+	} else if s.OnError != nil {
+		if c := s.OnError(d.err); c != nil {
+			buf.Reset()
+			if err := c.Render(ctx, &buf); err == nil {
+				d.body = buf.Bytes()
+			}
+		}
+	}
+
+	s.mu.Lock()
+	s.ready = append(s.ready, d)
+	s.mu.Unlock()
+
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
+// Len returns the number of sections deferred so far.
+func (s *Stream) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.next
+}
+
+// Send writes shell, the page rendered with s, to w up to its closing
+// </body> and flushes it. Each deferred section follows as a chunk as soon
+// as it completes, then the rest of shell. It stops early if ctx is done.
+// Failed sections without an error component are left out.
+func (s *Stream) Send(ctx context.Context, w io.Writer, shell []byte, flush func()) error {
+	var tail []byte
+	if i := bytes.LastIndex(shell, []byte("</body>")); i >= 0 {
+		shell, tail = shell[:i], shell[i:]
+	}
+	if _, err := w.Write(shell); err != nil {
+		return err
+	}
+	if flush != nil {
+		flush()
+	}
+
+	for {
+		s.mu.Lock()
+		ready := s.ready
+		s.ready = nil
+		s.pending -= len(ready)
+		pending := s.pending
+		s.mu.Unlock()
+
+		for _, d := range ready {
+			if d.body == nil {
+				continue
+			}
+			if !s.script {
+				if _, err := io.WriteString(w, swapScript); err != nil {
+					return err
+				}
+				s.script = true
+			}
+			if _, err := fmt.Fprintf(w, `<template id="zd-c%d">%s</template><script>$zd(%d)</script>`, d.id, d.body, d.id); err != nil {
+				return err
+			}
+		}
+		if len(ready) > 0 && flush != nil {
+			flush()
+		}
+		if pending == 0 {
+			break
+		}
+
+		select {
+		case <-s.notify:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	_, err := w.Write(tail)
+	return err
+}
@@ -0,0 +1,152 @@
+package render
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/a-h/templ"
+)
+
+func TestDefer_Inline(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Defer(templ.Raw("slow"), templ.Raw("loading")).Render(context.Background(), &buf); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != "slow" {
+		t.Errorf("Defer() without a stream = %q, want %q", got, "slow")
+	}
+}
+
+func TestStream_Send(t *testing.T) {
+	release := make(chan struct{})
+	first := templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+		<-release
+		_, err := io.WriteString(w, "first")
+		return err
+	})
+	second := templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+		defer close(release)
+		// Sections deferred within a deferred one render in place.
+		return Defer(templ.Raw("second"), nil).Render(ctx, w)
+	})
+	failing := templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+		return errors.New("boom")
+	})
+
+	s := NewStream()
+	s.Fallback = templ.Raw("loading")
+	s.OnError = func(err error) Component {
+		return templ.Raw("error: " + err.Error())
+	}
+	ctx := WithStream(context.Background(), s)
+
+	var shell bytes.Buffer
+	shell.WriteString("<html><body>")
+	for _, c := range []Component{Defer(first, templ.Raw("wait")), Defer(second, nil), Defer(failing, nil)} {
+		if err := c.Render(ctx, &shell); err != nil {
+			t.Fatal(err)
+		}
+	}
+	shell.WriteString("</body></html>")
+
+	if s.Len() != 3 {
+		t.Fatalf("Len() = %d, want 3", s.Len())
+	}
+	wantShell := `<html><body>` +
+		`<zeptor-deferred id="zd-0" style="display:contents">wait</zeptor-deferred>` +
+		`<zeptor-deferred id="zd-1" style="display:contents">loading</zeptor-deferred>` +
+		`<zeptor-deferred id="zd-2" style="display:contents">loading</zeptor-deferred>`
+	if !strings.HasPrefix(shell.String(), wantShell) {
+		t.Fatalf("shell = %q, want prefix %q", shell.String(), wantShell)
+	}
+
+	var out bytes.Buffer
+	flushes := 0
+	if err := s.Send(context.Background(), &out, shell.Bytes(), func() { flushes++ }); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	got := out.String()
+	if !strings.HasPrefix(got, wantShell+swapScript) {
+		t.Errorf("Send() does not start with the shell and swap script: %q", got)
+	}
+	if !strings.HasSuffix(got, "</body></html>") {
+		t.Errorf("Send() does not end with the shell's closing tags: %q", got)
+	}
+	if strings.Count(got, swapScript) != 1 {
+		t.Errorf("Send() wrote the swap script %d times, want 1", strings.Count(got, swapScript))
+	}
+	for _, chunk := range []string{
+		`<template id="zd-c0">first</template><script>$zd(0)</script>`,
+		`<template id="zd-c1">second</template><script>$zd(1)</script>`,
+		`<template id="zd-c2">error: boom</template><script>$zd(2)</script>`,
+	} {
+		if !strings.Contains(got, chunk) {
+			t.Errorf("Send() missing chunk %q in %q", chunk, got)
+		}
+	}
+	if strings.Index(got, "zd-c1") > strings.Index(got, "zd-c0") {
+		t.Errorf("Send() wrote chunks in render order, want completion order: %q", got)
+	}
+	if flushes < 2 {
+		t.Errorf("flushes = %d, want the shell and chunks flushed separately", flushes)
+	}
+}
+
+func TestStream_SendCanceled(t *testing.T) {
+	block := make(chan struct{})
+	defer close(block)
+
+	s := NewStream()
+	var shell bytes.Buffer
+	slow := templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+		<-block
+		return nil
+	})
+	if err := Defer(slow, nil).Render(WithStream(context.Background(), s), &shell); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := s.Send(ctx, io.Discard, shell.Bytes(), nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("Send() error = %v, want context.Canceled", err)
+	}
+}
+
+func TestDefer_OwnTemplState(t *testing.T) {
+	s := NewStream()
+	base := WithMetadata(context.Background(), Metadata{Title: "Post"})
+	ctx := templ.WithChildren(WithStream(base, s), templ.Raw("page"))
+
+	section := templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+		if title := MetadataFrom(ctx).Title; title != "Post" {
+			t.Errorf("deferred section metadata title = %q, want Post", title)
+		}
+		var leaked bytes.Buffer
+		templ.GetChildren(ctx).Render(ctx, &leaked)
+		if leaked.Len() > 0 {
+			t.Errorf("deferred section sees the page's children %q", leaked.String())
+		}
+		return templ.GetChildren(templ.WithChildren(ctx, templ.Raw("card"))).Render(ctx, w)
+	})
+
+	var shell bytes.Buffer
+	if err := Defer(section, nil).Render(ctx, &shell); err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err := s.Send(ctx, &out, shell.Bytes(), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var children bytes.Buffer
+	templ.GetChildren(ctx).Render(ctx, &children)
+	if children.String() != "page" || !strings.Contains(out.String(), ">card</template>") {
+		t.Errorf("children = %q, output = %q", children.String(), out.String())
+	}
+}
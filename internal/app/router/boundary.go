@@ -0,0 +1,204 @@
+package router
+
+import (
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/brattlof/zeptor/internal/app/render"
+)
+
+type BoundaryKind string
+
+const (
+	BoundaryNotFound BoundaryKind = "not-found"
+	BoundaryError    BoundaryKind = "error"
+	BoundaryLoading  BoundaryKind = "loading"
+)
+
+// boundaryFiles maps the special files in a segment to their kind.
+var boundaryFiles = map[string]BoundaryKind{
+	"not-found.templ": BoundaryNotFound,
+	"not-found.go":    BoundaryNotFound,
+	"error.templ":     BoundaryError,
+	"error.go":        BoundaryError,
+	"loading.templ":   BoundaryLoading,
+	"loading.go":      BoundaryLoading,
+}
+
+// Func is the component a boundary package exports, e.g. NotFound.
+func (k BoundaryKind) Func() string {
+	switch k {
+	case BoundaryNotFound:
+		return "NotFound"
+	case BoundaryError:
+		return "Error"
+	default:
+		return "Loading"
+	}
+}
+
+// Boundary is a not-found, error or loading component that applies to its
+// directory and everything below it.
+type Boundary struct {
+	Kind      BoundaryKind
+	Pattern   string
+	Dir       string
+	File      string
+	Params    []string
+	Component PageFunc
+}
+
+func (r *Router) addBoundary(kind BoundaryKind, relPath, fullPath string) error {
+	dir := relDir(relPath)
+	for _, existing := range r.boundaries {
+		if existing.Kind == kind && existing.Dir == dir {
+			return nil
+		}
+	}
+
+	pattern, params, err := parsePath(dir)
+	if err != nil {
+		r.report(SeverityError, DiagnosticInvalidSegment, "", []string{fullPath}, "%s: %v", relPath, err)
+		return nil
+	}
+
+	r.insertBoundary(&Boundary{
+		Kind:    kind,
+		Pattern: pattern,
+		Dir:     dir,
+		File:    fullPath,
+		Params:  params,
+	})
+	return nil
+}
+
+func (r *Router) HandleBoundary(kind BoundaryKind, dir string, component PageFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, b := range r.boundaries {
+		if b.Kind == kind && b.Dir == dir {
+			b.Component = component
+			return
+		}
+	}
+
+	pattern, params, _ := parsePath(dir)
+	r.insertBoundary(&Boundary{
+		Kind:      kind,
+		Pattern:   pattern,
+		Dir:       dir,
+		Params:    params,
+		Component: component,
+	})
+}
+
+// insertBoundary adds b after the boundaries at its depth or above, so that
+// they stay sorted from the root down without readers sorting them.
+func (r *Router) insertBoundary(b *Boundary) {
+	depth := dirDepth(b.Dir)
+	i := sort.Search(len(r.boundaries), func(i int) bool {
+		return dirDepth(r.boundaries[i].Dir) > depth
+	})
+	r.boundaries = slices.Insert(r.boundaries, i, b)
+}
+
+// Boundaries returns a copy of the boundaries, from the root down.
+func (r *Router) Boundaries() []*Boundary {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return slices.Clone(r.boundaries)
+}
+
+// BoundaryFor returns the nearest boundary of kind at or above dir.
+func (r *Router) BoundaryFor(kind BoundaryKind, dir string) *Boundary {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var nearest *Boundary
+	for _, b := range r.boundaries {
+		if b.Kind != kind || b.Component == nil {
+			continue
+		}
+		if b.Dir != "/" && dir != b.Dir && !strings.HasPrefix(dir, b.Dir+"/") {
+			continue
+		}
+		if nearest == nil || dirDepth(b.Dir) > dirDepth(nearest.Dir) {
+			nearest = b
+		}
+	}
+	return nearest
+}
+
+// NotFoundFor returns the not-found boundary for an unmatched path: the one
+// whose pattern covers the most leading segments of path, along with the
+// params captured by that prefix.
+func (r *Router) NotFoundFor(path string) (*Boundary, map[string]string) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var nearest *Boundary
+	var nearestParams map[string]string
+	depth := -1
+
+	for _, b := range r.boundaries {
+		if b.Kind != BoundaryNotFound || b.Component == nil {
+			continue
+		}
+
+		segs := patternSegments(b.Pattern)
+		params, ok := matchPrefix(segs, path)
+		if ok && len(segs) > depth {
+			nearest, nearestParams, depth = b, params, len(segs)
+		}
+	}
+
+	return nearest, nearestParams
+}
+
+func matchPrefix(segs []segment, path string) (map[string]string, bool) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	params := make(map[string]string)
+
+	for i, seg := range segs {
+		if seg.kind == segmentCatchAll || seg.kind == segmentOptionalCatchAll {
+			params[seg.name] = strings.Join(parts[i:], "/")
+			return params, true
+		}
+		if i >= len(parts) || parts[i] == "" {
+			return nil, false
+		}
+
+		switch seg.kind {
+		case segmentStatic:
+			if parts[i] != seg.name {
+				return nil, false
+			}
+		case segmentParam:
+			match, _ := compileConstraint(seg.constraint)
+			if match != nil && !match(parts[i]) {
+				return nil, false
+			}
+			params[seg.name] = parts[i]
+		}
+	}
+
+	return params, true
+}
+
+// BoundaryComponent composes b with the layouts of its own segment and
+// those above it, after running their loaders.
+func (r *Router) BoundaryComponent(req *http.Request, b *Boundary) (render.Component, error) {
+	chain := r.layoutChain(b.Dir)
+	req, err := load(req, nil, chain)
+	if err != nil {
+		return nil, err
+	}
+	component, err := b.Component(req)
+	if err != nil {
+		return nil, err
+	}
+	return wrapLayouts(req, component, chain)
+}
@@ -0,0 +1,76 @@
+package router
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+)
+
+type constraintFunc func(string) bool
+
+var namedConstraints = map[string]constraintFunc{
+	"int":  isInt,
+	"uuid": isUUID,
+}
+
+// compiledConstraints caches regular expressions, which URL helpers check
+// on every call.
+var compiledConstraints sync.Map
+
+func compileConstraint(constraint string) (constraintFunc, error) {
+	if constraint == "" {
+		return nil, nil
+	}
+	if fn, ok := namedConstraints[constraint]; ok {
+		return fn, nil
+	}
+	if fn, ok := compiledConstraints.Load(constraint); ok {
+		return fn.(constraintFunc), nil
+	}
+
+	re, err := regexp.Compile(`^(?:` + constraint + `)$`)
+	if err != nil {
+		return nil, fmt.Errorf("invalid constraint %q: %w", constraint, err)
+	}
+	compiledConstraints.Store(constraint, constraintFunc(re.MatchString))
+	return re.MatchString, nil
+}
+
+func isInt(s string) bool {
+	if len(s) > 0 && s[0] == '-' {
+		s = s[1:]
+	}
+	if s == "" || len(s) > 18 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func isUUID(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		switch i {
+		case 8, 13, 18, 23:
+			if s[i] != '-' {
+				return false
+			}
+		default:
+			c := s[i]
+			if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (r *Route) Constraints() map[string]string {
+	return patternConstraints(r.Pattern)
+}
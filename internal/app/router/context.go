@@ -2,7 +2,10 @@ package router
 
 import (
 	"context"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 )
 
 type contextKey string
@@ -10,11 +13,16 @@ type contextKey string
 const (
 	RouteParamsKey contextKey = "routeParams"
 	RouteKey       contextKey = "route"
+	RouteErrorKey  contextKey = "routeError"
+	PropsKey       contextKey = "props"
 )
 
 type RouteParams struct {
 	Params map[string]string
 	Route  *Route
+
+	// list holds the params set by WithParamList while Params is nil.
+	list Params
 }
 
 func ParamsMiddleware(next http.Handler) http.Handler {
@@ -29,9 +37,30 @@ func ParamsMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+func WithParams(r *http.Request, route *Route, params map[string]string) *http.Request {
+	ctx := context.WithValue(r.Context(), RouteParamsKey, &RouteParams{
+		Params: params,
+		Route:  route,
+	})
+	return r.WithContext(ctx)
+}
+
+// WithParamList is WithParams for params in the form Find captures them,
+// which Param reads without building a map.
+func WithParamList(r *http.Request, route *Route, params Params) *http.Request {
+	ctx := context.WithValue(r.Context(), RouteParamsKey, &RouteParams{
+		Route: route,
+		list:  params,
+	})
+	return r.WithContext(ctx)
+}
+
 func SetParams(r *http.Request, params map[string]string) {
 	ctx := r.Context()
 	if p, ok := ctx.Value(RouteParamsKey).(*RouteParams); ok {
+		if p.Params == nil {
+			p.Params = p.list.Map()
+		}
 		for k, v := range params {
 			p.Params[k] = v
 		}
@@ -41,17 +70,48 @@ func SetParams(r *http.Request, params map[string]string) {
 func GetParams(r *http.Request) map[string]string {
 	ctx := r.Context()
 	if p, ok := ctx.Value(RouteParamsKey).(*RouteParams); ok {
+		if p.Params == nil {
+			return p.list.Map()
+		}
 		return p.Params
 	}
 	return nil
 }
 
 func Param(r *http.Request, name string) string {
-	params := GetParams(r)
-	if params != nil {
-		return params[name]
+	p, ok := r.Context().Value(RouteParamsKey).(*RouteParams)
+	if !ok {
+		return ""
 	}
-	return ""
+	if p.Params == nil {
+		return p.list.Get(name)
+	}
+	return p.Params[name]
+}
+
+func ParamSegments(r *http.Request, name string) []string {
+	value := Param(r, name)
+	if value == "" {
+		return nil
+	}
+	return strings.Split(strings.Trim(value, "/"), "/")
+}
+
+func ParamInt(r *http.Request, name string) (int, error) {
+	value := Param(r, name)
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("param %s: %w", name, err)
+	}
+	return n, nil
+}
+
+func ParamUUID(r *http.Request, name string) (string, error) {
+	value := Param(r, name)
+	if !isUUID(value) {
+		return "", fmt.Errorf("param %s: invalid UUID %q", name, value)
+	}
+	return strings.ToLower(value), nil
 }
 
 func SetRoute(r *http.Request, route *Route) {
@@ -68,3 +128,13 @@ func GetRouteFromContext(r *http.Request) *Route {
 	}
 	return nil
 }
+
+// WithError attaches the error an error boundary is rendering for.
+func WithError(r *http.Request, err error) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), RouteErrorKey, err))
+}
+
+func RouteError(r *http.Request) error {
+	err, _ := r.Context().Value(RouteErrorKey).(error)
+	return err
+}
@@ -0,0 +1,208 @@
+package router
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+type Severity int
+
+const (
+	SeverityWarning Severity = iota
+	SeverityError
+)
+
+func (s Severity) String() string {
+	if s == SeverityError {
+		return "error"
+	}
+	return "warning"
+}
+
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+const (
+	DiagnosticDuplicate         = "duplicate"
+	DiagnosticInvalidSegment    = "invalid-segment"
+	DiagnosticInvalidHost       = "invalid-host"
+	DiagnosticInvalidLocale     = "invalid-locale"
+	DiagnosticInvalidRenderMode = "invalid-render-mode"
+	DiagnosticInvalidRule       = "invalid-rule"
+	DiagnosticMissingMessage    = "missing-message"
+	DiagnosticParamConflict     = "param-conflict"
+	DiagnosticShadowed          = "shadowed"
+)
+
+type Diagnostic struct {
+	Severity Severity `json:"severity"`
+	Kind     string   `json:"kind"`
+	Pattern  string   `json:"pattern,omitempty"`
+	Files    []string `json:"files"`
+	Message  string   `json:"message"`
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s [%s] %s", d.Severity, d.Kind, d.Message)
+}
+
+// Diagnostics is returned by New when the app tree has errors. It lists
+// warnings found in the same pass as well.
+type Diagnostics []Diagnostic
+
+func (d Diagnostics) Error() string {
+	lines := make([]string, len(d))
+	for i, diag := range d {
+		lines[i] = diag.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (d Diagnostics) HasErrors() bool {
+	for _, diag := range d {
+		if diag.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Router) Diagnostics() Diagnostics {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return slices.Clone(r.diagnostics)
+}
+
+func (r *Router) report(severity Severity, kind, pattern string, files []string, format string, args ...any) {
+	r.diagnostics = append(r.diagnostics, Diagnostic{
+		Severity: severity,
+		Kind:     kind,
+		Pattern:  pattern,
+		Files:    files,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+func (r *Router) checkRoutes() {
+	type paramOwner struct {
+		name  string
+		route *Route
+	}
+
+	owners := make(map[string]paramOwner)
+	segments := make(map[*Route][]segment, len(r.routes))
+
+	for _, route := range r.routes {
+		segs := patternSegments(route.Pattern)
+		segments[route] = segs
+
+		for i, seg := range segs {
+			if seg.kind == segmentStatic {
+				continue
+			}
+
+			key := segmentKey(segs[:i+1])
+			owner, ok := owners[key]
+			if !ok {
+				owners[key] = paramOwner{name: seg.name, route: route}
+				continue
+			}
+			if owner.name != seg.name {
+				r.report(SeverityError, DiagnosticParamConflict, route.Pattern, []string{owner.route.File, route.File},
+					"%s and %s use different param names (%q and %q) for the same segment",
+					owner.route.Pattern, route.Pattern, owner.name, seg.name)
+				break
+			}
+		}
+	}
+
+	for i, a := range r.routes {
+		for j, b := range r.routes {
+			if i == j {
+				continue
+			}
+			if covers(segments[a], segments[b], i < j) {
+				r.report(SeverityWarning, DiagnosticShadowed, b.Pattern, []string{a.File, b.File},
+					"%s is unreachable: %s matches every path it does and takes precedence", b.Pattern, a.Pattern)
+			}
+		}
+	}
+}
+
+func patternSegments(pattern string) []segment {
+	var segs []segment
+	for _, part := range strings.Split(strings.Trim(pattern, "/"), "/") {
+		if part == "" {
+			continue
+		}
+		seg, err := parseSegment(part)
+		if err != nil {
+			seg = segment{kind: segmentStatic, name: part}
+		}
+		segs = append(segs, seg)
+	}
+	return segs
+}
+
+// segmentKey identifies a position in the URL space regardless of param
+// names, e.g. /blog/{:int} for /blog/{id:int}.
+func segmentKey(segs []segment) string {
+	var b strings.Builder
+	for _, seg := range segs {
+		b.WriteString("/")
+		if seg.kind == segmentStatic {
+			b.WriteString(seg.name)
+			continue
+		}
+		seg.name = ""
+		b.WriteString(seg.String())
+	}
+	return b.String()
+}
+
+// covers reports whether a matches every path b does and wins each of
+// them, leaving b unreachable. Among routes with the same segment keys the
+// first one added wins; a static segment wins over a param whose
+// constraint only accepts that segment.
+func covers(a, b []segment, first bool) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		switch {
+		case a[i].kind == segmentStatic && b[i].kind == segmentStatic:
+			if a[i].name != b[i].name {
+				return false
+			}
+		case a[i].kind == segmentStatic && b[i].kind == segmentParam:
+			if !literalConstraint(b[i].constraint, a[i].name) {
+				return false
+			}
+		case a[i].kind == b[i].kind && a[i].constraint == b[i].constraint:
+			if a[i].name != b[i].name && !first {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// literalConstraint reports whether constraint accepts value and nothing
+// else, as {action:new} does for "new".
+func literalConstraint(constraint, value string) bool {
+	if constraint == "" || namedConstraints[constraint] != nil {
+		return false
+	}
+	re, err := regexp.Compile(constraint)
+	if err != nil {
+		return false
+	}
+	literal, complete := re.LiteralPrefix()
+	return complete && literal == value
+}
@@ -0,0 +1,194 @@
+package router
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Host serves requests for a hostname pattern such as docs.example.com or
+// {tenant}.example.com, either from its own app directory or from a
+// subtree of the parent router under Prefix. Params captured from the
+// hostname are added to the route params.
+type Host struct {
+	Pattern string
+	AppDir  string
+	Prefix  string
+	Params  []string
+	Router  *Router
+
+	labels      []segment
+	constraints []constraintFunc
+}
+
+// AddHost routes requests for pattern to the app tree in appDir, or when
+// appDir is empty, to the routes under prefix in this router.
+func (r *Router) AddHost(pattern, appDir, prefix string) error {
+	host, err := parseHost(pattern)
+	if err != nil {
+		return err
+	}
+	host.AppDir = appDir
+
+	switch {
+	case appDir != "" && prefix != "":
+		return fmt.Errorf("host %s: set either appDir or prefix, not both", pattern)
+	case appDir != "":
+		if _, err := os.Stat(appDir); err != nil {
+			return fmt.Errorf("host %s: %w", pattern, err)
+		}
+		sub, err := New(appDir)
+		if err != nil {
+			return fmt.Errorf("host %s: %w", pattern, err)
+		}
+		host.Router = sub
+	case prefix != "":
+		if !strings.HasPrefix(prefix, "/") {
+			return fmt.Errorf("host %s: prefix %q must start with /", pattern, prefix)
+		}
+		host.Prefix = strings.TrimRight(prefix, "/")
+	default:
+		return fmt.Errorf("host %s: needs an appDir or a prefix", pattern)
+	}
+
+	r.insertHost(host)
+	return nil
+}
+
+// insertHost keeps hosts with fewer params first, so that static
+// hostnames match before patterns.
+func (r *Router) insertHost(host *Host) {
+	r.hosts = append(r.hosts, host)
+	sort.SliceStable(r.hosts, func(i, j int) bool {
+		return len(r.hosts[i].Params) < len(r.hosts[j].Params)
+	})
+}
+
+var hostLabel = regexp.MustCompile(`^[A-Za-z0-9](?:[A-Za-z0-9-]*[A-Za-z0-9])?$`)
+
+// parseHost splits pattern into labels. A label is either static or a
+// single {param}, optionally constrained like a route segment.
+func parseHost(pattern string) (*Host, error) {
+	h := &Host{Pattern: pattern}
+
+	for _, label := range strings.Split(pattern, ".") {
+		if !strings.HasPrefix(label, "{") {
+			if !hostLabel.MatchString(label) {
+				return nil, fmt.Errorf("host %s: invalid label %q", pattern, label)
+			}
+			h.labels = append(h.labels, segment{kind: segmentStatic, name: strings.ToLower(label)})
+			h.constraints = append(h.constraints, nil)
+			continue
+		}
+
+		seg, err := parseSegment(label)
+		if err != nil {
+			return nil, fmt.Errorf("host %s: %w", pattern, err)
+		}
+		if seg.kind != segmentParam {
+			return nil, fmt.Errorf("host %s: invalid label %q", pattern, label)
+		}
+		match, _ := compileConstraint(seg.constraint)
+		h.labels = append(h.labels, seg)
+		h.constraints = append(h.constraints, match)
+		h.Params = append(h.Params, seg.name)
+	}
+
+	return h, nil
+}
+
+func (r *Router) Hosts() []*Host {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return slices.Clone(r.hosts)
+}
+
+// Host returns the router serving an app-directory host registered as
+// pattern, or nil.
+func (r *Router) Host(pattern string) *Router {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, h := range r.hosts {
+		if h.Pattern == pattern {
+			return h.Router
+		}
+	}
+	return nil
+}
+
+func (h *Host) match(hostname string) (map[string]string, bool) {
+	labels := strings.Split(hostname, ".")
+	if len(labels) != len(h.labels) {
+		return nil, false
+	}
+
+	var params map[string]string
+	for i, seg := range h.labels {
+		if seg.kind == segmentStatic {
+			if labels[i] != seg.name {
+				return nil, false
+			}
+			continue
+		}
+
+		if labels[i] == "" || h.constraints[i] != nil && !h.constraints[i](labels[i]) {
+			return nil, false
+		}
+		if params == nil {
+			params = make(map[string]string)
+		}
+		params[seg.name] = labels[i]
+	}
+	return params, true
+}
+
+// ResolveHost returns the router that serves a request for path on host,
+// the path to look up in it and the params captured from the hostname.
+// Hosts that match no pattern are served by r itself.
+func (r *Router) ResolveHost(host, path string) (*Router, string, map[string]string) {
+	if len(r.hosts) == 0 {
+		return r, path, nil
+	}
+
+	hostname := host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		hostname = h
+	}
+	hostname = strings.TrimSuffix(strings.ToLower(hostname), ".")
+
+	for _, h := range r.hosts {
+		params, ok := h.match(hostname)
+		if !ok {
+			continue
+		}
+		if h.Router != nil {
+			return h.Router, path, params
+		}
+		if h.Prefix != "" {
+			path = h.Prefix + strings.TrimSuffix(path, "/")
+		}
+		return r, path, params
+	}
+	return r, path, nil
+}
+
+// LookupHost is Lookup for a request to host, with the hostname params
+// merged into the route params.
+func (r *Router) LookupHost(host, path string) (*Route, map[string]string) {
+	ps := AcquireParams()
+	defer ReleaseParams(ps)
+
+	rt, path, hostParams := r.ResolveHost(host, path)
+	for k, v := range hostParams {
+		*ps = append(*ps, PathParam{Key: k, Value: v})
+	}
+	route := rt.Find(path, ps)
+	if route == nil {
+		return nil, nil
+	}
+	return route, ps.Map()
+}
@@ -0,0 +1,106 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/brattlof/zeptor/internal/app/render"
+)
+
+func (r *Router) HandleLayout(dir string, layout LayoutFunc) {
+	for _, l := range r.layouts {
+		if l.Dir == dir {
+			l.Component = layout
+			return
+		}
+	}
+
+	r.layouts = append(r.layouts, &Layout{Pattern: dir, Dir: dir, Component: layout})
+	r.resolveLayouts()
+}
+
+func (r *Router) LayoutsFor(pattern string) []*Layout {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, route := range r.routes {
+		if route.Pattern == pattern {
+			return route.Layouts
+		}
+	}
+	return nil
+}
+
+func (r *Router) resolveLayouts() {
+	sort.SliceStable(r.layouts, func(i, j int) bool {
+		return dirDepth(r.layouts[i].Dir) < dirDepth(r.layouts[j].Dir)
+	})
+
+	for _, route := range r.routes {
+		if route.Type == RouteTypePage {
+			route.Layouts = r.layoutChain(route.Dir)
+		}
+	}
+}
+
+func (r *Router) layoutChain(dir string) []*Layout {
+	if dir == "" {
+		return nil
+	}
+
+	var chain []*Layout
+	for _, layout := range r.layouts {
+		if layout.Dir == "/" || dir == layout.Dir || strings.HasPrefix(dir, layout.Dir+"/") {
+			chain = append(chain, layout)
+		}
+	}
+	return chain
+}
+
+func dirDepth(dir string) int {
+	if dir == "/" {
+		return 0
+	}
+	return strings.Count(dir, "/")
+}
+
+// Component runs the page's loaders and composes the page with its
+// layouts.
+func (r *Route) Component(req *http.Request) (render.Component, error) {
+	req, err := r.Load(req)
+	if err != nil {
+		return nil, err
+	}
+	page, err := r.Page(req)
+	if err != nil {
+		return nil, err
+	}
+	return wrapLayouts(req, page, r.Layouts)
+}
+
+// Partial runs the page's own loader and returns the page without its
+// layouts.
+func (r *Route) Partial(req *http.Request) (render.Component, error) {
+	req, err := load(req, r.Loader, nil)
+	if err != nil {
+		return nil, err
+	}
+	return r.Page(req)
+}
+
+func wrapLayouts(req *http.Request, component render.Component, chain []*Layout) (render.Component, error) {
+	layouts := make([]render.Component, 0, len(chain))
+	for _, layout := range chain {
+		if layout.Component == nil {
+			continue
+		}
+		c, err := layout.Component(req)
+		if err != nil {
+			return nil, fmt.Errorf("layout %s: %w", layout.Dir, err)
+		}
+		layouts = append(layouts, c)
+	}
+
+	return render.WithLayouts(component, layouts...), nil
+}
@@ -0,0 +1,186 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// LoadFunc loads the props a page or layout is rendered with.
+type LoadFunc func(ctx context.Context, r *http.Request) (any, error)
+
+// Props holds what the loaders of a page and its layouts returned. Layouts
+// are keyed by their directory.
+type Props struct {
+	Page    any
+	Layouts map[string]any
+}
+
+// ErrNotFound is returned by NotFound.
+var ErrNotFound = errors.New("not found")
+
+// RedirectError is returned by Redirect and PermanentRedirect.
+type RedirectError struct {
+	URL    string
+	Status int
+}
+
+func (e *RedirectError) Error() string {
+	return fmt.Sprintf("redirect %d to %s", e.Status, e.URL)
+}
+
+// NotFound makes a loader's page render its segment's not-found.templ with
+// a 404, e.g. when the post it looked up does not exist.
+func NotFound() error {
+	return ErrNotFound
+}
+
+// Redirect makes a loader's page redirect to url with a 307.
+func Redirect(url string) error {
+	return &RedirectError{URL: url, Status: http.StatusTemporaryRedirect}
+}
+
+// PermanentRedirect makes a loader's page redirect to url with a 308.
+func PermanentRedirect(url string) error {
+	return &RedirectError{URL: url, Status: http.StatusPermanentRedirect}
+}
+
+// HandleLoad registers the loader of the page at pattern.
+func (r *Router) HandleLoad(pattern string, load LoadFunc) {
+	r.bind(pattern, RouteTypePage).Loader = load
+}
+
+// HandleLayoutLoad registers the loader of the layout in dir.
+func (r *Router) HandleLayoutLoad(dir string, load LoadFunc) {
+	for _, l := range r.layouts {
+		if l.Dir == dir {
+			l.Loader = load
+			return
+		}
+	}
+
+	r.layouts = append(r.layouts, &Layout{Pattern: dir, Dir: dir, Loader: load})
+	r.resolveLayouts()
+}
+
+// Load runs the loaders of the page and its layouts in parallel and returns
+// req with their props attached. The first to fail cancels the others.
+func (r *Route) Load(req *http.Request) (*http.Request, error) {
+	return load(req, r.Loader, r.Layouts)
+}
+
+// Data runs only the page's own loader and returns its props, or nil for
+// a page without one.
+func (r *Route) Data(req *http.Request) (any, error) {
+	if r.Loader == nil {
+		return nil, nil
+	}
+	req, err := load(req, r.Loader, nil)
+	if err != nil {
+		return nil, err
+	}
+	return LoadedProps(req).Page, nil
+}
+
+func load(req *http.Request, page LoadFunc, chain []*Layout) (*http.Request, error) {
+	type job struct {
+		dir  string
+		load LoadFunc
+	}
+	var jobs []job
+	for _, layout := range chain {
+		if layout.Loader != nil {
+			jobs = append(jobs, job{layout.Dir, layout.Loader})
+		}
+	}
+	if page != nil {
+		jobs = append(jobs, job{"", page})
+	}
+	if len(jobs) == 0 {
+		return req, nil
+	}
+
+	ctx, cancel := context.WithCancel(req.Context())
+	defer cancel()
+
+	results := make([]any, len(jobs))
+	errs := make([]error, len(jobs))
+	var wg sync.WaitGroup
+	for i, j := range jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() {
+				if v := recover(); v != nil {
+					errs[i] = fmt.Errorf("panic: %v", v)
+					cancel()
+				}
+			}()
+			results[i], errs[i] = j.load(ctx, req.WithContext(ctx))
+			if errs[i] != nil {
+				cancel()
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Report the failure that caused the others, not a context.Canceled
+	// it led to.
+	var first error
+	for i, err := range errs {
+		if err == nil {
+			continue
+		}
+		if jobs[i].dir != "" {
+			err = fmt.Errorf("load layout %s: %w", jobs[i].dir, err)
+		}
+		if first == nil || errors.Is(first, context.Canceled) {
+			first = err
+		}
+	}
+	if first != nil {
+		return nil, first
+	}
+
+	props := &Props{Layouts: make(map[string]any)}
+	for i, j := range jobs {
+		if j.dir == "" {
+			props.Page = results[i]
+		} else {
+			props.Layouts[j.dir] = results[i]
+		}
+	}
+	return req.WithContext(context.WithValue(req.Context(), PropsKey, props)), nil
+}
+
+// LoadedProps returns the props loaded for the page being rendered, or nil.
+func LoadedProps(r *http.Request) *Props {
+	props, _ := r.Context().Value(PropsKey).(*Props)
+	return props
+}
+
+// PageProps returns the props the page's loader returned. load only fixes
+// their type, so generated code can pass the page's Load, e.g.
+// slug_.Page(router.PageProps(r, slug_.Load)).
+func PageProps[T any](r *http.Request, load func(context.Context, *http.Request) (T, error)) T {
+	var zero T
+	if props := LoadedProps(r); props != nil {
+		if v, ok := props.Page.(T); ok {
+			return v
+		}
+	}
+	return zero
+}
+
+// LayoutProps returns the props the loader of the layout in dir returned.
+func LayoutProps[T any](r *http.Request, dir string, load func(context.Context, *http.Request) (T, error)) T {
+	var zero T
+	if props := LoadedProps(r); props != nil {
+		if v, ok := props.Layouts[dir].(T); ok {
+			return v
+		}
+	}
+	return zero
+}
@@ -0,0 +1,258 @@
+package router
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"path/filepath"
+
+	"github.com/brattlof/zeptor/internal/app/render"
+)
+
+// ManifestFile is written by zt build next to the generated routes, so
+// it can be embedded in the binary.
+const ManifestFile = "routes.manifest.json"
+
+// ManifestVersion is bumped whenever the manifest format changes.
+const ManifestVersion = 1
+
+// Manifest is the discovered route tree in a form that can be shipped
+// without the app directory.
+type Manifest struct {
+	Version     int                        `json:"version"`
+	Routes      []ManifestRoute            `json:"routes"`
+	Layouts     []ManifestLayout           `json:"layouts,omitempty"`
+	Boundaries  []ManifestBoundary         `json:"boundaries,omitempty"`
+	Middlewares []ManifestMiddleware       `json:"middleware,omitempty"`
+	Metadata    map[string]render.Metadata `json:"metadata,omitempty"`
+	Hosts       []ManifestHost             `json:"hosts,omitempty"`
+}
+
+type ManifestRoute struct {
+	Pattern string   `json:"pattern"`
+	Type    string   `json:"type"`
+	Params  []string `json:"params,omitempty"`
+	Methods []string `json:"methods,omitempty"`
+	File    string   `json:"file"`
+	Dir     string   `json:"dir"`
+	Layouts []string `json:"layouts,omitempty"`
+}
+
+type ManifestLayout struct {
+	Pattern string   `json:"pattern"`
+	Dir     string   `json:"dir"`
+	File    string   `json:"file"`
+	Params  []string `json:"params,omitempty"`
+}
+
+type ManifestBoundary struct {
+	Kind    BoundaryKind `json:"kind"`
+	Pattern string       `json:"pattern"`
+	Dir     string       `json:"dir"`
+	File    string       `json:"file"`
+	Params  []string     `json:"params,omitempty"`
+}
+
+// ManifestHost is a host added with AddHost. A host with an app directory
+// of its own carries that app's routes in App.
+type ManifestHost struct {
+	Pattern string    `json:"pattern"`
+	AppDir  string    `json:"appDir,omitempty"`
+	Prefix  string    `json:"prefix,omitempty"`
+	App     *Manifest `json:"app,omitempty"`
+}
+
+type ManifestMiddleware struct {
+	Pattern string   `json:"pattern"`
+	Dir     string   `json:"dir"`
+	File    string   `json:"file"`
+	Matcher []string `json:"matcher,omitempty"`
+}
+
+func (t RouteType) String() string {
+	switch t {
+	case RouteTypeAPI:
+		return "api"
+	case RouteTypeLayout:
+		return "layout"
+	default:
+		return "page"
+	}
+}
+
+func parseRouteType(s string) (RouteType, error) {
+	switch s {
+	case "page":
+		return RouteTypePage, nil
+	case "api":
+		return RouteTypeAPI, nil
+	case "layout":
+		return RouteTypeLayout, nil
+	}
+	return 0, fmt.Errorf("unknown route type %q", s)
+}
+
+// Manifest describes the routes r discovered. Files are named relative to
+// the app directory.
+func (r *Router) Manifest() *Manifest {
+	m := &Manifest{Version: ManifestVersion}
+
+	for _, route := range r.Routes() {
+		if route.File == "" {
+			continue
+		}
+		var layouts []string
+		for _, l := range route.Layouts {
+			layouts = append(layouts, l.Dir)
+		}
+		m.Routes = append(m.Routes, ManifestRoute{
+			Pattern: route.Pattern,
+			Type:    route.Type.String(),
+			Params:  route.Params,
+			Methods: route.Methods,
+			File:    r.relFile(route.File),
+			Dir:     route.Dir,
+			Layouts: layouts,
+		})
+	}
+	for _, l := range r.layouts {
+		if l.File != "" {
+			m.Layouts = append(m.Layouts, ManifestLayout{l.Pattern, l.Dir, r.relFile(l.File), l.Params})
+		}
+	}
+	for _, b := range r.Boundaries() {
+		if b.File != "" {
+			m.Boundaries = append(m.Boundaries, ManifestBoundary{b.Kind, b.Pattern, b.Dir, r.relFile(b.File), b.Params})
+		}
+	}
+	for _, mw := range r.middlewares {
+		if mw.File != "" {
+			m.Middlewares = append(m.Middlewares, ManifestMiddleware{mw.Pattern, mw.Dir, r.relFile(mw.File), mw.Matcher})
+		}
+	}
+	if len(r.metadata) > 0 {
+		m.Metadata = r.metadata
+	}
+	for _, h := range r.hosts {
+		mh := ManifestHost{Pattern: h.Pattern, AppDir: h.AppDir, Prefix: h.Prefix}
+		if h.Router != nil {
+			mh.App = h.Router.Manifest()
+		}
+		m.Hosts = append(m.Hosts, mh)
+	}
+
+	return m
+}
+
+func (r *Router) relFile(file string) string {
+	if r.root == "" {
+		return file
+	}
+	if rel, err := filepath.Rel(r.root, file); err == nil {
+		return filepath.ToSlash(rel)
+	}
+	return file
+}
+
+func (r *Router) WriteManifest(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r.Manifest())
+}
+
+// LoadManifest builds a router from the ManifestFile at the root of fsys,
+// e.g. an embed.FS, without reading the app directory.
+func LoadManifest(fsys fs.FS) (*Router, error) {
+	src, err := fs.ReadFile(fsys, ManifestFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var m Manifest
+	if err := json.Unmarshal(src, &m); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", ManifestFile, err)
+	}
+	r, err := FromManifest(&m)
+	if err != nil {
+		return nil, err
+	}
+	r.fsys = fsys
+	return r, nil
+}
+
+// FromManifest builds a router with the routes in m and its hosts.
+// Handlers are then registered as usual by the generated registerRoutes.
+func FromManifest(m *Manifest) (*Router, error) {
+	if m.Version != ManifestVersion {
+		return nil, fmt.Errorf("%s: version %d, want %d; rebuild with zt build", ManifestFile, m.Version, ManifestVersion)
+	}
+
+	r := newRouter("")
+	r.fromManifest = true
+
+	for _, mr := range m.Routes {
+		routeType, err := parseRouteType(mr.Type)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s: %w", ManifestFile, mr.Pattern, err)
+		}
+		methods := mr.Methods
+		if len(methods) == 0 && routeType == RouteTypePage {
+			methods = []string{http.MethodGet}
+		}
+		r.addRoute(&Route{
+			Pattern:   mr.Pattern,
+			Params:    mr.Params,
+			IsDynamic: len(mr.Params) > 0,
+			Type:      routeType,
+			File:      mr.File,
+			Dir:       mr.Dir,
+			Methods:   methods,
+		})
+	}
+	for _, l := range m.Layouts {
+		r.layouts = append(r.layouts, &Layout{Pattern: l.Pattern, Dir: l.Dir, File: l.File, Params: l.Params})
+	}
+	for _, b := range m.Boundaries {
+		r.insertBoundary(&Boundary{Kind: b.Kind, Pattern: b.Pattern, Dir: b.Dir, File: b.File, Params: b.Params})
+	}
+	for _, mw := range m.Middlewares {
+		middleware := &Middleware{Pattern: mw.Pattern, Dir: mw.Dir, File: mw.File, Matcher: mw.Matcher}
+		if len(mw.Matcher) > 0 {
+			matcher, err := NewPathMatcher(mw.Matcher...)
+			if err != nil {
+				return nil, fmt.Errorf("%s: middleware %s: %w", ManifestFile, mw.Dir, err)
+			}
+			middleware.matcher = matcher
+		}
+		r.middlewares = append(r.middlewares, middleware)
+	}
+	for dir, meta := range m.Metadata {
+		r.setMetadata(dir, meta)
+	}
+	for _, mh := range m.Hosts {
+		host, err := parseHost(mh.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", ManifestFile, err)
+		}
+		host.AppDir, host.Prefix = mh.AppDir, mh.Prefix
+		if mh.App != nil {
+			if host.Router, err = FromManifest(mh.App); err != nil {
+				return nil, fmt.Errorf("host %s: %w", mh.Pattern, err)
+			}
+		}
+		r.insertHost(host)
+	}
+
+	if r.diagnostics.HasErrors() {
+		return nil, r.diagnostics
+	}
+
+	r.resolveLayouts()
+	r.resolveMiddlewares()
+	r.resolveMetadata()
+	r.buildTree()
+
+	return r, nil
+}
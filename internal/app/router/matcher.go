@@ -0,0 +1,110 @@
+package router
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PathMatcher matches request paths against route patterns outside the app
+// tree, such as middleware matchers and configured redirects.
+type PathMatcher struct {
+	tree     *radixNode
+	patterns []string
+}
+
+func NewPathMatcher(patterns ...string) (*PathMatcher, error) {
+	m := &PathMatcher{tree: newRadixNode("", nodeStatic)}
+	for _, pattern := range patterns {
+		if err := validatePattern(pattern); err != nil {
+			return nil, err
+		}
+		m.tree.insert(pattern, &Route{Pattern: pattern})
+		m.patterns = append(m.patterns, pattern)
+	}
+	return m, nil
+}
+
+func validatePattern(pattern string) error {
+	if !strings.HasPrefix(pattern, "/") {
+		return fmt.Errorf("pattern %q must start with /", pattern)
+	}
+	for _, part := range strings.Split(strings.Trim(pattern, "/"), "/") {
+		if !strings.HasPrefix(part, "{") {
+			if strings.ContainsAny(part, "{}") {
+				return fmt.Errorf("pattern %q: invalid segment %q", pattern, part)
+			}
+			continue
+		}
+		seg, err := parseSegment(part)
+		if err != nil {
+			return fmt.Errorf("pattern %q: %w", pattern, err)
+		}
+		if _, err := compileConstraint(seg.constraint); err != nil {
+			return fmt.Errorf("pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// Match reports whether path matches one of the patterns, returning the
+// pattern and the params it captured.
+func (m *PathMatcher) Match(path string) (string, map[string]string, bool) {
+	if len(path) > 1 && path[len(path)-1] == '/' {
+		path = path[:len(path)-1]
+	}
+
+	ps := AcquireParams()
+	defer ReleaseParams(ps)
+
+	route := m.tree.search(path, ps)
+	if route == nil {
+		return "", nil, false
+	}
+	return route.Pattern, ps.Map(), true
+}
+
+// Covers reports whether one of the patterns matches every path the route
+// pattern does, comparing them segment by segment: /users/{id} and
+// /users/{...rest} cover /users/{id:int}, /users/{id:uuid} does not.
+func (m *PathMatcher) Covers(pattern string) bool {
+	for _, p := range m.patterns {
+		if patternCovers(p, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+func patternCovers(match, pattern string) bool {
+	ms, ps := patternSegments(match), patternSegments(pattern)
+	for i, m := range ms {
+		switch m.kind {
+		case segmentCatchAll:
+			return i < len(ps)
+		case segmentOptionalCatchAll:
+			return true
+		}
+		if i >= len(ps) {
+			return false
+		}
+
+		p := ps[i]
+		switch {
+		case m.kind == segmentStatic:
+			if p.kind != segmentStatic || p.name != m.name {
+				return false
+			}
+		case p.kind == segmentStatic:
+			if fn, _ := compileConstraint(m.constraint); fn != nil && !fn(p.name) {
+				return false
+			}
+		case p.kind == segmentParam:
+			if m.constraint != "" && m.constraint != p.constraint {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return len(ms) == len(ps)
+}
@@ -0,0 +1,152 @@
+package router
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path"
+	"reflect"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/brattlof/zeptor/internal/app/render"
+)
+
+const metadataFile = "meta.yaml"
+
+func (r *Router) addMetadataFile(relPath, fullPath string) error {
+	src, err := fs.ReadFile(r.fsys, relPath)
+	if err != nil {
+		return err
+	}
+
+	var m render.Metadata
+	if err := yaml.Unmarshal(src, &m); err != nil {
+		return fmt.Errorf("parse %s: %w", relPath, err)
+	}
+
+	r.setMetadata(relDir(relPath), m)
+	return nil
+}
+
+// addMetadataDecl picks up a literal `var Metadata = render.Metadata{...}`
+// from page.go or layout.go, so zt routes can show it without running the
+// generated code. Other values are registered through HandleMetadata.
+func (r *Router) addMetadataDecl(relPath, fullPath string) error {
+	src, err := fs.ReadFile(r.fsys, relPath)
+	if err != nil {
+		return err
+	}
+
+	file, err := parser.ParseFile(token.NewFileSet(), fullPath, src, parser.SkipObjectResolution)
+	if err != nil {
+		return fmt.Errorf("parse %s: %w", relPath, err)
+	}
+
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.VAR {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			vs := spec.(*ast.ValueSpec)
+			for i, name := range vs.Names {
+				if name.Name != "Metadata" || i >= len(vs.Values) {
+					continue
+				}
+				lit, ok := vs.Values[i].(*ast.CompositeLit)
+				if !ok {
+					continue
+				}
+				var m render.Metadata
+				decodeLiteral(reflect.ValueOf(&m).Elem(), lit)
+				r.setMetadata(relDir(relPath), m)
+			}
+		}
+	}
+	return nil
+}
+
+// decodeLiteral sets the string and struct fields of v named in lit,
+// ignoring anything that is not a constant.
+func decodeLiteral(v reflect.Value, lit *ast.CompositeLit) {
+	for _, elt := range lit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			continue
+		}
+		key, ok := kv.Key.(*ast.Ident)
+		if !ok {
+			continue
+		}
+		field := v.FieldByName(key.Name)
+		if !field.IsValid() {
+			continue
+		}
+
+		switch value := kv.Value.(type) {
+		case *ast.BasicLit:
+			if s, err := strconv.Unquote(value.Value); err == nil && field.Kind() == reflect.String {
+				field.SetString(s)
+			}
+		case *ast.CompositeLit:
+			if field.Kind() == reflect.Struct {
+				decodeLiteral(field, value)
+			}
+		}
+	}
+}
+
+func (r *Router) setMetadata(dir string, m render.Metadata) {
+	if r.metadata == nil {
+		r.metadata = make(map[string]render.Metadata)
+	}
+	r.metadata[dir] = r.metadata[dir].Merge(m)
+}
+
+// HandleMetadata sets the metadata declared in dir, replacing anything
+// discovered there.
+func (r *Router) HandleMetadata(dir string, m render.Metadata) {
+	if r.metadata == nil {
+		r.metadata = make(map[string]render.Metadata)
+	}
+	if r.handledMeta == nil {
+		r.handledMeta = make(map[string]render.Metadata)
+	}
+	r.metadata[dir] = m
+	r.handledMeta[dir] = m
+	r.resolveMetadata()
+}
+
+// MetadataFor merges the metadata of dir and its parents, root first.
+func (r *Router) MetadataFor(dir string) render.Metadata {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var dirs []string
+	for d := dir; d != "" && d != "."; d = path.Dir(d) {
+		dirs = append(dirs, d)
+		if d == "/" {
+			break
+		}
+	}
+
+	var m render.Metadata
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if dm, ok := r.metadata[dirs[i]]; ok {
+			m = m.Merge(dm)
+		}
+	}
+	return m
+}
+
+func (r *Router) resolveMetadata() {
+	for _, route := range r.routes {
+		if route.Type == RouteTypePage && route.Dir != "" {
+			route.Metadata = r.MetadataFor(route.Dir)
+		}
+	}
+}
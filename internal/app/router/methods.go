@@ -0,0 +1,101 @@
+package router
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"net/http"
+)
+
+const AnyMethod = "*"
+
+var httpMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+	http.MethodOptions,
+}
+
+func isHTTPMethod(name string) bool {
+	for _, m := range httpMethods {
+		if m == name {
+			return true
+		}
+	}
+	return false
+}
+
+func parseRouteMethods(filename string, src []byte) ([]string, error) {
+	file, err := parser.ParseFile(token.NewFileSet(), filename, src, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+
+	declared := make(map[string]bool)
+	hasHandler := false
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Recv != nil {
+			continue
+		}
+		if isHTTPMethod(fn.Name.Name) {
+			declared[fn.Name.Name] = true
+		} else if fn.Name.Name == "Handler" {
+			hasHandler = true
+		}
+	}
+
+	methods := make([]string, 0, len(declared))
+	for _, m := range httpMethods {
+		if declared[m] {
+			methods = append(methods, m)
+		}
+	}
+
+	if len(methods) == 0 && hasHandler {
+		methods = append(methods, AnyMethod)
+	}
+
+	return methods, nil
+}
+
+func (r *Route) hasMethod(method string) bool {
+	for _, m := range r.Methods {
+		if m == method || m == AnyMethod {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Route) Allows(method string) bool {
+	if r.hasMethod(method) {
+		return true
+	}
+	return method == http.MethodHead && r.hasMethod(http.MethodGet)
+}
+
+func (r *Route) Allow() []string {
+	allow := make([]string, 0, len(httpMethods))
+	for _, m := range httpMethods {
+		if r.Allows(m) || m == http.MethodOptions {
+			allow = append(allow, m)
+		}
+	}
+	return allow
+}
+
+func (r *Route) MethodHandler(method string) http.HandlerFunc {
+	if h, ok := r.handlers[method]; ok {
+		return h
+	}
+	if method == http.MethodHead {
+		if h, ok := r.handlers[http.MethodGet]; ok {
+			return h
+		}
+	}
+	return r.Handler
+}
@@ -0,0 +1,168 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+)
+
+type MiddlewareFunc func(http.Handler) http.Handler
+
+// MiddlewareConfig is declared as Config next to Middleware in a
+// middleware.go to limit it to paths matching one of the patterns, e.g.
+// "/admin/{...rest}".
+type MiddlewareConfig struct {
+	Matcher []string
+}
+
+type Middleware struct {
+	Pattern string
+	Dir     string
+	File    string
+	Matcher []string
+	Handler MiddlewareFunc
+
+	matcher *PathMatcher
+}
+
+func (r *Router) addMiddleware(relPath, fullPath string) error {
+	dir := relDir(relPath)
+	pattern, _, err := parsePath(dir)
+	if err != nil {
+		r.report(SeverityError, DiagnosticInvalidSegment, "", []string{fullPath}, "%s: %v", relPath, err)
+		return nil
+	}
+
+	r.middlewares = append(r.middlewares, &Middleware{
+		Pattern: pattern,
+		Dir:     dir,
+		File:    fullPath,
+	})
+	return nil
+}
+
+// HandleMiddleware installs mw for every route at or below dir. When
+// matcher patterns are given, mw only runs for paths matching one of them;
+// an invalid pattern is returned and nothing is installed.
+func (r *Router) HandleMiddleware(dir string, mw MiddlewareFunc, matcher ...string) error {
+	var pm *PathMatcher
+	if len(matcher) > 0 {
+		var err error
+		if pm, err = NewPathMatcher(matcher...); err != nil {
+			return fmt.Errorf("middleware %s: %w", dir, err)
+		}
+	}
+
+	var m *Middleware
+	for _, existing := range r.middlewares {
+		if existing.Dir == dir {
+			m = existing
+			break
+		}
+	}
+	if m == nil {
+		pattern, _, _ := parsePath(dir)
+		m = &Middleware{Pattern: pattern, Dir: dir}
+		r.middlewares = append(r.middlewares, m)
+	}
+
+	m.Handler = mw
+	m.Matcher = matcher
+	m.matcher = pm
+
+	r.resolveMiddlewares()
+	return nil
+}
+
+func (r *Router) Middlewares() []*Middleware {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return slices.Clone(r.middlewares)
+}
+
+// MiddlewareChain returns the middleware that applies to dir, root first.
+func (r *Router) MiddlewareChain(dir string) []*Middleware {
+	var chain []*Middleware
+	for _, m := range r.middlewares {
+		if m.Dir == "/" || dir == m.Dir || strings.HasPrefix(dir, m.Dir+"/") {
+			chain = append(chain, m)
+		}
+	}
+	return chain
+}
+
+func (r *Router) resolveMiddlewares() {
+	sort.SliceStable(r.middlewares, func(i, j int) bool {
+		return dirDepth(r.middlewares[i].Dir) < dirDepth(r.middlewares[j].Dir)
+	})
+
+	for _, route := range r.routes {
+		route.Middlewares = route.Middlewares[:0]
+		route.chain = nil
+		if route.Dir == "" {
+			continue
+		}
+		for _, m := range r.MiddlewareChain(route.Dir) {
+			if m.Handler != nil {
+				route.Middlewares = append(route.Middlewares, m.wrap)
+			}
+		}
+		route.buildChain()
+	}
+}
+
+type nextKey struct{}
+
+// buildChain runs the middleware constructors once, so that what they set
+// up, such as a rate limiter, lasts across requests.
+func (r *Route) buildChain() {
+	if len(r.Middlewares) == 0 {
+		return
+	}
+
+	var h http.Handler = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if next, ok := req.Context().Value(nextKey{}).(http.Handler); ok {
+			next.ServeHTTP(w, req)
+		}
+	})
+	for i := len(r.Middlewares) - 1; i >= 0; i-- {
+		h = r.Middlewares[i](h)
+	}
+	r.chain = h
+}
+
+// Matches reports whether path is one the middleware's matcher accepts.
+func (m *Middleware) Matches(path string) bool {
+	if m.matcher == nil {
+		return true
+	}
+	_, _, ok := m.matcher.Match(path)
+	return ok
+}
+
+func (m *Middleware) wrap(next http.Handler) http.Handler {
+	handler := m.Handler(next)
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if !m.Matches(req.URL.Path) {
+			next.ServeHTTP(w, req)
+			return
+		}
+		handler.ServeHTTP(w, req)
+	})
+}
+
+// Wrap applies the route's middleware to h, the root directory's outermost.
+// The chain is built when the middleware is resolved; h is handed to it
+// with each request.
+func (r *Route) Wrap(h http.Handler) http.Handler {
+	chain := r.chain
+	if chain == nil {
+		return h
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		chain.ServeHTTP(w, req.WithContext(context.WithValue(req.Context(), nextKey{}, h)))
+	})
+}
@@ -0,0 +1,45 @@
+package router
+
+import "sync"
+
+type PathParam struct {
+	Key   string
+	Value string
+}
+
+// Params holds the values captured by a lookup, in pattern order. Use
+// AcquireParams and ReleaseParams to reuse them across requests.
+type Params []PathParam
+
+func (ps Params) Get(name string) string {
+	for _, p := range ps {
+		if p.Key == name {
+			return p.Value
+		}
+	}
+	return ""
+}
+
+func (ps Params) Map() map[string]string {
+	m := make(map[string]string, len(ps))
+	for _, p := range ps {
+		m[p.Key] = p.Value
+	}
+	return m
+}
+
+var paramsPool = sync.Pool{
+	New: func() any {
+		ps := make(Params, 0, 8)
+		return &ps
+	},
+}
+
+func AcquireParams() *Params {
+	return paramsPool.Get().(*Params)
+}
+
+func ReleaseParams(ps *Params) {
+	*ps = (*ps)[:0]
+	paramsPool.Put(ps)
+}
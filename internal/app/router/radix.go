@@ -1,6 +1,9 @@
 package router
 
-import "strings"
+import (
+	"sort"
+	"strings"
+)
 
 type nodeType int
 
@@ -10,212 +13,270 @@ const (
 	nodeCatchAll
 )
 
+// radixNode is one edge of the route tree. Static nodes hold a byte prefix
+// and are split where patterns diverge; param and catch-all nodes consume a
+// path segment or the rest of the path. Lookups try static children first,
+// then wildcards in wildcardRank order, backtracking on a dead end.
 type radixNode struct {
-	path     string
-	children []*radixNode
-	wildcard *radixNode
-	nType    nodeType
-	param    string
-	handler  *Route
-	indices  string
-	priority uint32
+	path       string
+	nType      nodeType
+	param      string
+	constraint string
+	match      constraintFunc
+	children   []*radixNode
+	indices    string
+	wildcards  []*radixNode
+	handler    *Route
+	fallback   bool
+	priority   uint32
 }
 
 func newRadixNode(path string, nType nodeType) *radixNode {
 	return &radixNode{
-		path:     path,
-		nType:    nType,
-		children: make([]*radixNode, 0),
+		path:  path,
+		nType: nType,
 	}
 }
 
+// insert adds pattern to the tree. An optional catch-all also registers its
+// parent path, unless another route already owns it.
 func (n *radixNode) insert(pattern string, route *Route) {
-	if pattern == "" || pattern == "/" {
-		n.handler = route
-		n.priority++
-		return
+	if parent, ok := optionalParent(pattern); ok {
+		n.add(parent, route, true)
 	}
+	n.add(pattern, route, false)
+}
 
-	pattern = trimPrefixSlash(pattern)
-
-	paramIdx := strings.Index(pattern, "{")
-	if paramIdx == 0 {
-		endIdx := strings.Index(pattern, "}")
-		if endIdx == -1 {
-			return
-		}
+func optionalParent(pattern string) (string, bool) {
+	if !strings.HasSuffix(pattern, "?}") {
+		return "", false
+	}
+	i := strings.LastIndex(pattern, "/{...")
+	if i == -1 {
+		return "", false
+	}
+	if i == 0 {
+		return "/", true
+	}
+	return pattern[:i], true
+}
 
-		paramName := pattern[1:endIdx]
-		rest := pattern[endIdx+1:]
-		rest = trimPrefixSlash(rest)
+func (n *radixNode) add(pattern string, route *Route, fallback bool) {
+	n.priority++
 
-		if n.wildcard == nil {
-			nType := nodeParam
-			if strings.HasPrefix(paramName, "...") {
-				nType = nodeCatchAll
-				paramName = paramName[3:]
-			}
-			n.wildcard = &radixNode{
-				nType: nType,
-				param: paramName,
-			}
+	if pattern == "" {
+		if n.handler == nil || n.fallback || !fallback {
+			n.handler = route
+			n.fallback = fallback
 		}
-
-		n.wildcard.priority++
-		n.wildcard.insert(rest, route)
 		return
 	}
 
-	if paramIdx > 0 {
-		staticPart := pattern[:paramIdx]
-		remaining := pattern[paramIdx:]
-
-		for i := range n.children {
-			child := n.children[i]
-			if child.nType != nodeStatic {
-				continue
-			}
-
-			commonPrefix := longestCommonPrefix(staticPart, child.path)
-			if len(commonPrefix) == 0 {
-				continue
-			}
-
-			if len(commonPrefix) < len(child.path) {
-				n.splitChild(i, commonPrefix)
-				child = n.children[i]
-			}
-
-			childRem := staticPart[len(commonPrefix):]
-			childRem = trimPrefixSlash(childRem)
-			if childRem == "" {
-				child.priority++
-				child.insert(remaining, route)
-			} else {
-				newChild := newRadixNode(childRem, nodeStatic)
-				newChild.insert(remaining, route)
-				child.addChild(newChild)
-			}
+	if pattern[0] == '{' {
+		end := paramEnd(pattern)
+		if end == -1 {
 			return
 		}
 
-		child := newRadixNode(staticPart, nodeStatic)
-		child.insert(remaining, route)
-		n.addChild(child)
+		wildcard, ok := n.wildcardFor(pattern[1:end])
+		if !ok {
+			return
+		}
+		wildcard.add(pattern[end+1:], route, fallback)
 		return
 	}
 
-	for i := range n.children {
-		child := n.children[i]
+	static := pattern
+	if i := strings.IndexByte(pattern, '{'); i != -1 {
+		static = pattern[:i]
+	}
 
-		commonPrefix := longestCommonPrefix(pattern, child.path)
-		if len(commonPrefix) == 0 {
+	for i := 0; i < len(n.indices); i++ {
+		if n.indices[i] != static[0] {
 			continue
 		}
 
-		if len(commonPrefix) < len(child.path) {
-			n.splitChild(i, commonPrefix)
-			child = n.children[i]
+		child := n.children[i]
+		common := len(longestCommonPrefix(static, child.path))
+		if common < len(child.path) {
+			child = n.splitChild(i, common)
 		}
-
-		remaining := pattern[len(commonPrefix):]
-		remaining = trimPrefixSlash(remaining)
-		child.priority++
-		child.insert(remaining, route)
+		child.add(pattern[common:], route, fallback)
+		n.reorder(i)
 		return
 	}
 
-	child := newRadixNode(pattern, nodeStatic)
-	child.handler = route
+	child := newRadixNode(static, nodeStatic)
 	n.addChild(child)
+	child.add(pattern[len(static):], route, fallback)
+	n.reorder(len(n.children) - 1)
 }
 
-func (n *radixNode) splitChild(index int, prefix string) {
-	child := n.children[index]
+func (n *radixNode) wildcardFor(token string) (*radixNode, bool) {
+	nType := nodeParam
+	param, constraint := token, ""
+
+	if strings.HasPrefix(param, "...") {
+		nType = nodeCatchAll
+		param = strings.TrimSuffix(param[3:], "?")
+	} else if i := strings.IndexByte(param, ':'); i != -1 {
+		param, constraint = param[:i], param[i+1:]
+	}
+
+	for _, wildcard := range n.wildcards {
+		if wildcard.nType == nType && wildcard.param == param && wildcard.constraint == constraint {
+			return wildcard, true
+		}
+	}
+
+	match, err := compileConstraint(constraint)
+	if err != nil {
+		return nil, false
+	}
 
-	fullPath := child.path
-	child.path = prefix
+	wildcard := &radixNode{
+		nType:      nType,
+		param:      param,
+		constraint: constraint,
+		match:      match,
+	}
+	n.wildcards = append(n.wildcards, wildcard)
+	sort.SliceStable(n.wildcards, func(i, j int) bool {
+		return wildcardRank(n.wildcards[i]) < wildcardRank(n.wildcards[j])
+	})
+	return wildcard, true
+}
 
-	remaining := fullPath[len(prefix):]
+// wildcardRank keeps constrained params ahead of plain params, and both
+// ahead of catch-alls, so lookups try the most specific match first.
+func wildcardRank(n *radixNode) int {
+	switch {
+	case n.nType == nodeCatchAll:
+		return 2
+	case n.constraint == "":
+		return 1
+	default:
+		return 0
+	}
+}
 
-	newChild := newRadixNode(remaining, child.nType)
-	newChild.handler = child.handler
-	newChild.children = child.children
-	newChild.wildcard = child.wildcard
-	newChild.param = child.param
+// splitChild cuts the static child at index so that its path is the first
+// common bytes, moving the rest of it into a new static grandchild.
+func (n *radixNode) splitChild(index, common int) *radixNode {
+	child := n.children[index]
+
+	rest := *child
+	rest.path = child.path[common:]
+
+	parent := &radixNode{
+		path:     child.path[:common],
+		nType:    nodeStatic,
+		children: []*radixNode{&rest},
+		indices:  rest.path[:1],
+		priority: child.priority,
+	}
 
-	child.handler = nil
-	child.children = []*radixNode{newChild}
-	child.wildcard = nil
-	child.param = ""
+	n.children[index] = parent
+	return parent
 }
 
 func (n *radixNode) addChild(child *radixNode) {
 	n.children = append(n.children, child)
-	if len(child.path) > 0 {
-		n.indices += string(child.path[0])
+	n.indices += child.path[:1]
+}
+
+// reorder moves the child at index towards the front while it has a higher
+// priority than its predecessor, so busy subtrees are scanned first.
+func (n *radixNode) reorder(index int) {
+	for index > 0 && n.children[index-1].priority < n.children[index].priority {
+		n.children[index-1], n.children[index] = n.children[index], n.children[index-1]
+		index--
+	}
+
+	indices := []byte(n.indices)
+	for i, child := range n.children {
+		indices[i] = child.path[0]
 	}
+	n.indices = string(indices)
 }
 
 func (n *radixNode) lookup(path string) (*Route, map[string]string) {
-	params := make(map[string]string)
-	result := n.search(path, params)
-	return result, params
-}
+	ps := AcquireParams()
+	defer ReleaseParams(ps)
 
-func (n *radixNode) search(path string, params map[string]string) *Route {
-	path = trimPrefixSlash(path)
+	route := n.search(path, ps)
+	return route, ps.Map()
+}
 
+func (n *radixNode) search(path string, ps *Params) *Route {
 	if path == "" {
 		return n.handler
 	}
 
-	for _, child := range n.children {
-		if child.nType != nodeStatic || len(child.path) == 0 {
+	c := path[0]
+	for i := 0; i < len(n.indices); i++ {
+		if n.indices[i] != c {
 			continue
 		}
-		if len(path) < len(child.path) {
-			continue
+		child := n.children[i]
+		if strings.HasPrefix(path, child.path) {
+			if route := child.search(path[len(child.path):], ps); route != nil {
+				return route
+			}
 		}
+		break
+	}
 
-		if path[:len(child.path)] != child.path {
+	for _, wildcard := range n.wildcards {
+		if wildcard.nType == nodeCatchAll {
+			if wildcard.handler != nil {
+				*ps = append(*ps, PathParam{Key: wildcard.param, Value: path})
+				return wildcard.handler
+			}
 			continue
 		}
 
-		remaining := path[len(child.path):]
-		remaining = trimPrefixSlash(remaining)
-
-		if result := child.search(remaining, params); result != nil {
-			return result
+		end := strings.IndexByte(path, '/')
+		if end == -1 {
+			end = len(path)
 		}
-	}
-
-	if n.wildcard != nil {
-		if n.wildcard.nType == nodeCatchAll {
-			params[n.wildcard.param] = path
-			return n.wildcard.handler
+		if end == 0 {
+			continue
 		}
 
-		slashIdx := strings.Index(path, "/")
-		var paramValue, remaining string
-
-		if slashIdx == -1 {
-			paramValue = path
-			remaining = ""
-		} else {
-			paramValue = path[:slashIdx]
-			remaining = path[slashIdx+1:]
+		value := path[:end]
+		if wildcard.match != nil && !wildcard.match(value) {
+			continue
 		}
 
-		if paramValue != "" {
-			params[n.wildcard.param] = paramValue
-			return n.wildcard.search(remaining, params)
+		*ps = append(*ps, PathParam{Key: wildcard.param, Value: value})
+		if route := wildcard.search(path[end:], ps); route != nil {
+			return route
 		}
+		*ps = (*ps)[:len(*ps)-1]
 	}
 
 	return nil
 }
 
+// paramEnd returns the index of the brace closing the param that starts
+// pattern, allowing braces inside constraints such as {code:[0-9]{3}}.
+func paramEnd(pattern string) int {
+	depth := 0
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
 func longestCommonPrefix(a, b string) string {
 	minLen := len(a)
 	if len(b) < minLen {
@@ -229,10 +290,3 @@ func longestCommonPrefix(a, b string) string {
 
 	return a[:i]
 }
-
-func trimPrefixSlash(s string) string {
-	if strings.HasPrefix(s, "/") {
-		return s[1:]
-	}
-	return s
-}
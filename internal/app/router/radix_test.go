@@ -0,0 +1,298 @@
+package router
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+var benchPatterns = []string{
+	"/",
+	"/about",
+	"/about/team",
+	"/abc",
+	"/api/users",
+	"/api/users/{id:int}",
+	"/api/users/{id:int}/posts",
+	"/api/users/{name}",
+	"/api/files/{...path}",
+	"/blog",
+	"/blog/{slug}",
+	"/blog/{slug}/comments/{comment}",
+	"/docs/{...slug?}",
+	"/shop/{category}/{product}",
+	"/shop/featured/{product}",
+	"/{page}",
+}
+
+func newBenchRouter(patterns []string) *Router {
+	r := newRouter("")
+	for _, pattern := range patterns {
+		r.HandlePage(pattern, nil)
+	}
+	return r
+}
+
+// naiveMatch is the reference matcher: it collects every route whose
+// segments accept path and keeps the most specific one, comparing segment
+// kinds left to right (static, constrained, param, catch-all).
+func naiveMatch(patterns []string, path string) (string, map[string]string) {
+	if len(path) > 1 && strings.HasSuffix(path, "/") {
+		path = path[:len(path)-1]
+	}
+	if path == "" {
+		path = "/"
+	}
+
+	var best string
+	var bestRank []int
+	var bestParams map[string]string
+
+	for _, pattern := range patterns {
+		rank, params, ok := naiveSegments(patternSegments(pattern), path)
+		if !ok {
+			if parent, optional := optionalParent(pattern); optional {
+				rank, params, ok = naiveSegments(patternSegments(parent), path)
+				rank = append(rank, 4)
+			}
+		}
+		if ok && (bestRank == nil || lessRank(rank, bestRank)) {
+			best, bestRank, bestParams = pattern, rank, params
+		}
+	}
+
+	return best, bestParams
+}
+
+func naiveSegments(segs []segment, path string) ([]int, map[string]string, bool) {
+	rank := make([]int, 0, len(segs))
+	params := make(map[string]string)
+	if len(segs) == 0 {
+		return rank, params, path == "/"
+	}
+
+	rest := path
+	for _, seg := range segs {
+		if !strings.HasPrefix(rest, "/") {
+			return nil, nil, false
+		}
+		rest = rest[1:]
+
+		if seg.kind == segmentCatchAll || seg.kind == segmentOptionalCatchAll {
+			if rest == "" {
+				return nil, nil, false
+			}
+			params[seg.name] = rest
+			return append(rank, 3), params, true
+		}
+
+		value := rest
+		if j := strings.IndexByte(rest, '/'); j != -1 {
+			value = rest[:j]
+		}
+		rest = rest[len(value):]
+
+		switch seg.kind {
+		case segmentStatic:
+			if value != seg.name {
+				return nil, nil, false
+			}
+			rank = append(rank, 0)
+		case segmentParam:
+			match, _ := compileConstraint(seg.constraint)
+			if value == "" || match != nil && !match(value) {
+				return nil, nil, false
+			}
+			params[seg.name] = value
+			if match != nil {
+				rank = append(rank, 1)
+			} else {
+				rank = append(rank, 2)
+			}
+		}
+	}
+
+	return rank, params, rest == ""
+}
+
+func lessRank(a, b []int) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return len(a) < len(b)
+}
+
+func checkAgainstNaive(t *testing.T, r *Router, patterns []string, path string) {
+	t.Helper()
+
+	want, wantParams := naiveMatch(patterns, path)
+	route, params := r.Lookup(path)
+
+	got := ""
+	if route != nil {
+		got = route.Pattern
+	}
+	if got != want {
+		t.Fatalf("Lookup(%q) = %q, naive = %q", path, got, want)
+	}
+	for k, v := range wantParams {
+		if params[k] != v {
+			t.Fatalf("Lookup(%q) params[%q] = %q, naive = %q", path, k, params[k], v)
+		}
+	}
+}
+
+func TestRadixTree_Backtracking(t *testing.T) {
+	r := newBenchRouter(benchPatterns)
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/abc", "/abc"},
+		{"/ab", "/{page}"},
+		{"/about/", "/about"},
+		{"/api/users/42", "/api/users/{id:int}"},
+		{"/api/users/42/posts", "/api/users/{id:int}/posts"},
+		{"/api/users/bob", "/api/users/{name}"},
+		{"/api/users/bob/posts", ""},
+		{"/shop/featured/lamp", "/shop/featured/{product}"},
+		{"/shop/garden/lamp", "/shop/{category}/{product}"},
+		{"/shop/featured", ""},
+		{"/docs", "/docs/{...slug?}"},
+		{"/docs/a/b", "/docs/{...slug?}"},
+		{"/api/files", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			route, _ := r.Lookup(tt.path)
+			got := ""
+			if route != nil {
+				got = route.Pattern
+			}
+			if got != tt.want {
+				t.Errorf("Lookup(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+			checkAgainstNaive(t, r, benchPatterns, tt.path)
+		})
+	}
+}
+
+func TestRadixTree_RandomTrees(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	words := []string{"a", "ab", "abc", "b", "ba", "users", "user", "42", "x"}
+
+	randomPattern := func() string {
+		var b strings.Builder
+		depth := 1 + rng.Intn(4)
+		for i := 0; i < depth; i++ {
+			b.WriteString("/")
+			if rng.Intn(3) > 0 {
+				b.WriteString(words[rng.Intn(len(words))])
+				continue
+			}
+			switch rng.Intn(3) {
+			case 0:
+				fmt.Fprintf(&b, "{p%d}", i)
+			case 1:
+				fmt.Fprintf(&b, "{n%d:int}", i)
+			default:
+				fmt.Fprintf(&b, "{...rest%d}", i)
+				return b.String()
+			}
+		}
+		return b.String()
+	}
+
+	randomPath := func() string {
+		var b strings.Builder
+		depth := rng.Intn(5)
+		for i := 0; i < depth; i++ {
+			b.WriteString("/")
+			b.WriteString(words[rng.Intn(len(words))])
+		}
+		if b.Len() == 0 {
+			return "/"
+		}
+		return b.String()
+	}
+
+	for round := 0; round < 200; round++ {
+		seen := make(map[string]bool)
+		var patterns []string
+		for len(patterns) < 12 {
+			pattern := randomPattern()
+			key := segmentKey(patternSegments(pattern))
+			if !seen[key] {
+				seen[key] = true
+				patterns = append(patterns, pattern)
+			}
+		}
+
+		r := newBenchRouter(patterns)
+		for i := 0; i < 50; i++ {
+			checkAgainstNaive(t, r, patterns, randomPath())
+		}
+	}
+}
+
+func FuzzLookup(f *testing.F) {
+	for _, seed := range []string{"/", "/about", "/api/users/42/posts", "/docs/a/b", "/shop/featured/x", "//", "/a//b/"} {
+		f.Add(seed)
+	}
+
+	r := newBenchRouter(benchPatterns)
+	f.Fuzz(func(t *testing.T, path string) {
+		if !strings.HasPrefix(path, "/") {
+			return
+		}
+		checkAgainstNaive(t, r, benchPatterns, path)
+	})
+}
+
+func TestRouter_FindAllocs(t *testing.T) {
+	r := newBenchRouter(benchPatterns)
+	ps := AcquireParams()
+	defer ReleaseParams(ps)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		*ps = (*ps)[:0]
+		r.Find("/blog/hello/comments/7", ps)
+		*ps = (*ps)[:0]
+		r.Find("/api/users/42/posts", ps)
+	})
+	if allocs != 0 {
+		t.Errorf("Find allocated %.0f times per run, want 0", allocs)
+	}
+}
+
+func BenchmarkRouter_Find(b *testing.B) {
+	r := newBenchRouter(benchPatterns)
+	paths := []string{"/", "/about/team", "/api/users/42/posts", "/blog/hello/comments/7", "/docs/guide/routing", "/shop/garden/lamp"}
+
+	for _, path := range paths {
+		b.Run(path, func(b *testing.B) {
+			ps := AcquireParams()
+			defer ReleaseParams(ps)
+
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				*ps = (*ps)[:0]
+				r.Find(path, ps)
+			}
+		})
+	}
+}
+
+func BenchmarkRouter_Lookup(b *testing.B) {
+	r := newBenchRouter(benchPatterns)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		r.Lookup("/blog/hello/comments/7")
+	}
+}
@@ -0,0 +1,254 @@
+package router
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// Diff lists the route patterns a Reload added, removed or changed. Routes
+// of host apps are prefixed with their host pattern.
+type Diff struct {
+	Added   []string
+	Removed []string
+	Changed []string
+}
+
+func (d *Diff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+func (d *Diff) String() string {
+	if d.Empty() {
+		return "no route changes"
+	}
+
+	var parts []string
+	for _, list := range []struct {
+		sign     string
+		patterns []string
+	}{{"+", d.Added}, {"-", d.Removed}, {"~", d.Changed}} {
+		for _, p := range list.patterns {
+			parts = append(parts, list.sign+p)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// Reload rediscovers the app directory and swaps the new routes in. The
+// handlers, layouts, boundaries, middleware and metadata registered
+// through the Handle methods carry over to the routes that still exist.
+// Lookups in flight keep the table they started with. On error, such as a
+// new duplicate route in the app or any of its hosts, the current routes
+// of all of them stay in place.
+func (r *Router) Reload() (*Diff, error) {
+	r.reloadMu.Lock()
+	defer r.reloadMu.Unlock()
+
+	diff, swap, unlock, err := r.prepareReload()
+	defer unlock()
+	if err != nil {
+		return nil, err
+	}
+	swap()
+	return diff, nil
+}
+
+// prepareReload rediscovers r and its hosts without changing them. swap
+// puts every new table in place; unlock releases the hosts' reload locks
+// taken along the way, and must be called even on error.
+func (r *Router) prepareReload() (diff *Diff, swap, unlock func(), err error) {
+	var swaps, unlocks []func()
+	unlock = func() {
+		for i := len(unlocks) - 1; i >= 0; i-- {
+			unlocks[i]()
+		}
+	}
+
+	next, err := r.rediscover()
+	if err != nil {
+		return nil, nil, unlock, err
+	}
+	next.adopt(r)
+	diff = diffRoutes(r.routes, next.routes)
+	swaps = append(swaps, func() { r.swap(next) })
+
+	for _, h := range r.hosts {
+		if h.Router == nil {
+			continue
+		}
+		h.Router.reloadMu.Lock()
+		unlocks = append(unlocks, h.Router.reloadMu.Unlock)
+
+		hostDiff, hostSwap, hostUnlock, err := h.Router.prepareReload()
+		unlocks = append(unlocks, hostUnlock)
+		if err != nil {
+			return nil, nil, unlock, fmt.Errorf("host %s: %w", h.Pattern, err)
+		}
+		swaps = append(swaps, hostSwap)
+
+		for _, list := range []struct{ dst, src *[]string }{
+			{&diff.Added, &hostDiff.Added},
+			{&diff.Removed, &hostDiff.Removed},
+			{&diff.Changed, &hostDiff.Changed},
+		} {
+			for _, p := range *list.src {
+				*list.dst = append(*list.dst, h.Pattern+p)
+			}
+		}
+	}
+
+	swap = func() {
+		for _, s := range swaps {
+			s()
+		}
+	}
+	return diff, swap, unlock, nil
+}
+
+func (r *Router) swap(next *Router) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.routes = next.routes
+	r.layouts = next.layouts
+	r.boundaries = next.boundaries
+	r.middlewares = next.middlewares
+	r.metadata = next.metadata
+	r.handledMeta = next.handledMeta
+	r.tree = next.tree
+	r.static = next.static
+	r.diagnostics = next.diagnostics
+	r.table.Store(next.table.Load())
+}
+
+func (r *Router) rediscover() (*Router, error) {
+	if r.fromManifest {
+		return nil, errors.New("routes loaded from a manifest cannot be reloaded")
+	}
+	if r.fsys == nil {
+		return New(r.appDir)
+	}
+
+	next := newRouter(r.appDir)
+	next.fsys, next.root = r.fsys, r.root
+	return next.discover()
+}
+
+// adopt carries what was registered on old over to r: handlers onto the
+// routes, layouts, boundaries and middleware r rediscovered, and copies of
+// anything registered without a file of its own.
+func (r *Router) adopt(old *Router) {
+	for _, o := range old.routes {
+		if route := r.findRoute(o.Pattern); route != nil {
+			if route.Type != o.Type {
+				continue
+			}
+			route.Page, route.Handler, route.handlers = o.Page, o.Handler, o.handlers
+			route.StaticParams, route.Loader = o.StaticParams, o.Loader
+			for method := range o.handlers {
+				if !route.hasMethod(method) {
+					route.Methods = append(route.Methods, method)
+				}
+			}
+			continue
+		}
+		if o.File == "" {
+			c := *o
+			c.Layouts, c.Middlewares = nil, nil
+			r.addRoute(&c)
+			r.tree.insert(c.Pattern, &c)
+		}
+	}
+
+	for _, o := range old.layouts {
+		if i := slices.IndexFunc(r.layouts, func(l *Layout) bool { return l.Dir == o.Dir }); i >= 0 {
+			r.layouts[i].Component, r.layouts[i].Loader = o.Component, o.Loader
+		} else if o.File == "" {
+			c := *o
+			r.layouts = append(r.layouts, &c)
+		}
+	}
+
+	for _, o := range old.boundaries {
+		if i := slices.IndexFunc(r.boundaries, func(b *Boundary) bool { return b.Kind == o.Kind && b.Dir == o.Dir }); i >= 0 {
+			r.boundaries[i].Component = o.Component
+		} else if o.File == "" {
+			c := *o
+			r.insertBoundary(&c)
+		}
+	}
+
+	for _, o := range old.middlewares {
+		if i := slices.IndexFunc(r.middlewares, func(m *Middleware) bool { return m.Dir == o.Dir }); i >= 0 {
+			m := r.middlewares[i]
+			m.Handler, m.Matcher, m.matcher = o.Handler, o.Matcher, o.matcher
+		} else if o.File == "" {
+			c := *o
+			r.middlewares = append(r.middlewares, &c)
+		}
+	}
+
+	for dir, m := range old.handledMeta {
+		r.HandleMetadata(dir, m)
+	}
+
+	r.resolveLayouts()
+	r.resolveMiddlewares()
+	r.resolveMetadata()
+}
+
+func (r *Router) findRoute(pattern string) *Route {
+	for _, route := range r.routes {
+		if route.Pattern == pattern {
+			return route
+		}
+	}
+	return nil
+}
+
+func diffRoutes(old, next []*Route) *Diff {
+	diff := &Diff{}
+	before := make(map[string]*Route, len(old))
+	for _, route := range old {
+		before[route.Pattern] = route
+	}
+
+	for _, route := range next {
+		prev, ok := before[route.Pattern]
+		delete(before, route.Pattern)
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, route.Pattern)
+		case routeChanged(prev, route):
+			diff.Changed = append(diff.Changed, route.Pattern)
+		}
+	}
+	for _, route := range old {
+		if _, ok := before[route.Pattern]; ok {
+			diff.Removed = append(diff.Removed, route.Pattern)
+		}
+	}
+
+	slices.Sort(diff.Added)
+	slices.Sort(diff.Removed)
+	slices.Sort(diff.Changed)
+	return diff
+}
+
+func routeChanged(a, b *Route) bool {
+	return a.Type != b.Type || a.File != b.File ||
+		!slices.Equal(a.Methods, b.Methods) ||
+		!slices.Equal(a.Params, b.Params) ||
+		!slices.Equal(layoutDirs(a.Layouts), layoutDirs(b.Layouts)) ||
+		!reflect.DeepEqual(a.Metadata, b.Metadata)
+}
+
+func layoutDirs(layouts []*Layout) []string {
+	dirs := make([]string, len(layouts))
+	for i, l := range layouts {
+		dirs[i] = l.Dir
+	}
+	return dirs
+}
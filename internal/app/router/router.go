@@ -3,12 +3,19 @@ package router
 import (
 	"context"
 	"fmt"
+	"html"
+	"io/fs"
 	"net/http"
 	"os"
+	"path"
 	"path/filepath"
-	"regexp"
+	"slices"
 	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/brattlof/zeptor/internal/app/render"
 )
 
 type RouteType int
@@ -19,47 +26,74 @@ const (
 	RouteTypeLayout
 )
 
+type PageFunc func(r *http.Request) (render.Component, error)
+
 type Route struct {
-	Pattern     string
-	Handler     http.HandlerFunc
-	Params      []string
-	IsDynamic   bool
-	Type        RouteType
-	File        string
-	Method      string
-	Middlewares []func(http.Handler) http.Handler
-	Children    []*Route
+	Pattern      string
+	Handler      http.HandlerFunc
+	Page         PageFunc
+	Params       []string
+	IsDynamic    bool
+	Type         RouteType
+	File         string
+	Dir          string
+	Methods      []string
+	Layouts      []*Layout
+	Metadata     render.Metadata
+	Middlewares  []func(http.Handler) http.Handler
+	Children     []*Route
+	StaticParams StaticParamsFunc
+	Loader       LoadFunc
+	handlers     map[string]http.HandlerFunc
+	// chain is Middlewares applied once, ending in the handler Wrap
+	// passes along with the request.
+	chain http.Handler
 }
 
+type LayoutFunc func(r *http.Request) (render.Component, error)
+
 type Layout struct {
-	Pattern string
-	File    string
-	Handler func(child http.Handler) http.Handler
+	Pattern   string
+	Dir       string
+	File      string
+	Params    []string
+	Component LayoutFunc
+	Loader    LoadFunc
 }
 
 type Router struct {
-	routes  []*Route
-	layouts []*Layout
-	tree    *radixNode
-	static  map[string]*Route
-	dynamic []*Route
-	appDir  string
+	routes      []*Route
+	layouts     []*Layout
+	boundaries  []*Boundary
+	middlewares []*Middleware
+	hosts       []*Host
+	metadata    map[string]render.Metadata
+	tree        *radixNode
+	static      map[string]*Route
+	appDir      string
+	fsys        fs.FS
+	root        string
+	// fromManifest routers have no source tree to reload from.
+	fromManifest bool
+
+	// table is what Find reads. Reload swaps it in one store so lookups
+	// never block or see a partly built tree; mu guards the rest of the
+	// state that requests read.
+	table       atomic.Pointer[routeTable]
+	mu          sync.RWMutex
+	reloadMu    sync.Mutex
+	handledMeta map[string]render.Metadata
+
+	diagnostics Diagnostics
 }
 
-var (
-	dynamicSegment  = regexp.MustCompile(`\{([^}]+)\}|\[([^\]]+)\]|_(\w+)|(\w+)_`)
-	catchAllSegment = regexp.MustCompile(`\{\.\.\.([^}]+)\}|\[\.\.\.([^\]]+)\]`)
-)
+type routeTable struct {
+	tree   *radixNode
+	static map[string]*Route
+}
 
 func New(appDir string) (*Router, error) {
-	r := &Router{
-		static:  make(map[string]*Route),
-		dynamic: make([]*Route, 0),
-		routes:  make([]*Route, 0),
-		layouts: make([]*Layout, 0),
-		tree:    newRadixNode("", nodeStatic),
-		appDir:  appDir,
-	}
+	r := newRouter(appDir)
 
 	absPath, err := filepath.Abs(appDir)
 	if err != nil {
@@ -70,169 +104,209 @@ func New(appDir string) (*Router, error) {
 		return r, nil
 	}
 
-	if err := r.discoverRoutes(absPath); err != nil {
+	r.fsys, r.root = os.DirFS(absPath), absPath
+	return r.discover()
+}
+
+// NewFS discovers routes from fsys, such as an embed.FS narrowed to the
+// app directory with fs.Sub. Route files are named by their path in fsys.
+func NewFS(fsys fs.FS) (*Router, error) {
+	r := newRouter("")
+	r.fsys = fsys
+	return r.discover()
+}
+
+// FS returns the files r was loaded from: its app directory, or the files
+// beside its manifest, which zt build copies the message catalogs into.
+func (r *Router) FS() fs.FS {
+	return r.fsys
+}
+
+func newRouter(appDir string) *Router {
+	r := &Router{
+		static:  make(map[string]*Route),
+		routes:  make([]*Route, 0),
+		layouts: make([]*Layout, 0),
+		tree:    newRadixNode("", nodeStatic),
+		appDir:  appDir,
+	}
+	r.table.Store(&routeTable{tree: r.tree, static: r.static})
+	return r
+}
+
+func (r *Router) discover() (*Router, error) {
+	if err := r.discoverRoutes(); err != nil {
 		return nil, err
 	}
 
+	r.checkRoutes()
+	if r.diagnostics.HasErrors() {
+		return nil, r.diagnostics
+	}
+
+	r.resolveLayouts()
+	r.resolveMiddlewares()
+	r.resolveMetadata()
 	r.buildTree()
 
 	return r, nil
 }
 
-func (r *Router) discoverRoutes(appDir string) error {
-	return filepath.Walk(appDir, func(path string, info os.FileInfo, err error) error {
+func (r *Router) discoverRoutes() error {
+	return fs.WalkDir(r.fsys, ".", func(relPath string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
 
-		if info.IsDir() {
-			if strings.HasPrefix(info.Name(), "_") || info.Name() == "node_modules" {
-				return filepath.SkipDir
+		if d.IsDir() {
+			if relPath != "." && (strings.HasPrefix(d.Name(), "_") || d.Name() == "node_modules") {
+				return fs.SkipDir
 			}
 			return nil
 		}
 
-		relPath := strings.TrimPrefix(path, appDir)
-		relPath = strings.TrimPrefix(relPath, string(filepath.Separator))
+		fullPath := r.filePath(relPath)
+		baseName := d.Name()
 
-		baseName := info.Name()
-		dirPath := filepath.Dir(path)
+		if baseName == "page.go" || baseName == "layout.go" {
+			if err := r.addMetadataDecl(relPath, fullPath); err != nil {
+				return err
+			}
+		}
 
 		switch baseName {
 		case "page.templ", "page.go":
-			r.addPageRoute(relPath, path)
-		case "layout.templ":
-			r.addLayoutRoute(relPath, path)
+			return r.addPageRoute(relPath, fullPath)
+		case "layout.templ", "layout.go":
+			return r.addLayoutRoute(relPath, fullPath)
+		case metadataFile:
+			return r.addMetadataFile(relPath, fullPath)
 		case "route.go":
-			r.addAPIRoute(relPath, path)
+			return r.addAPIRoute(relPath, fullPath)
+		case "middleware.go":
+			return r.addMiddleware(relPath, fullPath)
 		}
 
-		_ = dirPath
+		if kind, ok := boundaryFiles[baseName]; ok {
+			return r.addBoundary(kind, relPath, fullPath)
+		}
 
 		return nil
 	})
 }
 
-func (r *Router) addPageRoute(relPath, fullPath string) {
-	relPath = filepath.ToSlash(relPath)
-	pattern := strings.TrimSuffix(relPath, "page.templ")
-	pattern = strings.TrimSuffix(pattern, "page.go")
-	pattern = strings.TrimSuffix(pattern, "/")
-	pattern = "/" + strings.TrimPrefix(pattern, "/")
-
-	params := []string{}
-	isDynamic := dynamicSegment.MatchString(pattern) || catchAllSegment.MatchString(pattern)
-
-	if isDynamic {
-		matches := dynamicSegment.FindAllStringSubmatch(pattern, -1)
-		for _, m := range matches {
-			if m[1] != "" {
-				params = append(params, m[1])
-			} else if m[2] != "" {
-				params = append(params, m[2])
-			} else if m[3] != "" {
-				params = append(params, m[3])
-			} else if m[4] != "" {
-				params = append(params, m[4])
-			}
-		}
-		matches = catchAllSegment.FindAllStringSubmatch(pattern, -1)
-		for _, m := range matches {
-			param := m[1]
-			if param == "" {
-				param = m[2]
-			}
-			params = append(params, "..."+param)
+func (r *Router) addPageRoute(relPath, fullPath string) error {
+	for _, existing := range r.routes {
+		if existing.Type == RouteTypePage && filepath.Dir(existing.File) == filepath.Dir(fullPath) {
+			return nil
 		}
-		pattern = normalizePattern(pattern)
 	}
 
-	if pattern == "/" {
-		pattern = "/"
-	} else {
-		pattern = strings.TrimSuffix(pattern, "/")
+	dir := relDir(relPath)
+	pattern, params, err := parsePath(dir)
+	if err != nil {
+		r.report(SeverityError, DiagnosticInvalidSegment, "", []string{fullPath}, "%s: %v", relPath, err)
+		return nil
 	}
 
-	route := &Route{
+	r.addRoute(&Route{
 		Pattern:   pattern,
 		Params:    params,
-		IsDynamic: isDynamic,
+		IsDynamic: len(params) > 0,
 		Type:      RouteTypePage,
 		File:      fullPath,
-		Method:    "GET",
+		Dir:       dir,
+		Methods:   []string{http.MethodGet},
+	})
+	return nil
+}
+
+func (r *Router) addRoute(route *Route) {
+	for _, existing := range r.routes {
+		if existing.Pattern == route.Pattern {
+			r.report(SeverityError, DiagnosticDuplicate, route.Pattern, []string{existing.File, route.File},
+				"%s and %s both resolve to %s", existing.File, route.File, route.Pattern)
+			return
+		}
 	}
 
-	if isDynamic {
-		r.dynamic = append(r.dynamic, route)
-	} else {
-		r.static[pattern] = route
+	if !route.IsDynamic {
+		r.static[route.Pattern] = route
 	}
 	r.routes = append(r.routes, route)
 }
 
-func (r *Router) addLayoutRoute(relPath, fullPath string) {
-	relPath = filepath.ToSlash(relPath)
-	pattern := strings.TrimSuffix(relPath, "layout.templ")
-	pattern = strings.TrimSuffix(pattern, "/")
-	pattern = "/" + strings.TrimPrefix(pattern, "/")
+// filePath names a discovered file: its path on disk for New, or in the
+// FS for NewFS.
+func (r *Router) filePath(relPath string) string {
+	if r.root == "" {
+		return relPath
+	}
+	return filepath.Join(r.root, filepath.FromSlash(relPath))
+}
 
-	if pattern == "" {
-		pattern = "/"
+func relDir(relPath string) string {
+	dir := path.Dir(filepath.ToSlash(relPath))
+	if dir == "." {
+		return "/"
 	}
+	return "/" + dir
+}
 
-	layout := &Layout{
-		Pattern: pattern,
-		File:    fullPath,
+func (r *Router) addLayoutRoute(relPath, fullPath string) error {
+	for _, existing := range r.layouts {
+		if filepath.Dir(existing.File) == filepath.Dir(fullPath) {
+			return nil
+		}
 	}
 
-	r.layouts = append(r.layouts, layout)
+	dir := relDir(relPath)
+	pattern, params, err := parsePath(dir)
+	if err != nil {
+		r.report(SeverityError, DiagnosticInvalidSegment, "", []string{fullPath}, "%s: %v", relPath, err)
+		return nil
+	}
+
+	r.layouts = append(r.layouts, &Layout{
+		Pattern: pattern,
+		Dir:     dir,
+		File:    fullPath,
+		Params:  params,
+	})
+	return nil
 }
 
-func (r *Router) addAPIRoute(relPath, fullPath string) {
-	relPath = filepath.ToSlash(relPath)
-	pattern := strings.TrimSuffix(relPath, "route.go")
-	pattern = strings.TrimSuffix(pattern, "/")
-	pattern = "/" + pattern
-	if pattern == "/" {
-		pattern = "/api"
+func (r *Router) addAPIRoute(relPath, fullPath string) error {
+	src, err := fs.ReadFile(r.fsys, relPath)
+	if err != nil {
+		return err
 	}
 
-	params := []string{}
-	isDynamic := dynamicSegment.MatchString(pattern) || catchAllSegment.MatchString(pattern)
-
-	if isDynamic {
-		matches := dynamicSegment.FindAllStringSubmatch(pattern, -1)
-		for _, m := range matches {
-			params = append(params, m[1])
-		}
-		pattern = normalizePattern(pattern)
+	methods, err := parseRouteMethods(fullPath, src)
+	if err != nil {
+		return fmt.Errorf("parse %s: %w", relPath, err)
 	}
 
-	pattern = strings.TrimSuffix(pattern, "/")
+	dir := relDir(relPath)
+	pattern, params, err := parsePath(dir)
+	if err != nil {
+		r.report(SeverityError, DiagnosticInvalidSegment, "", []string{fullPath}, "%s: %v", relPath, err)
+		return nil
+	}
+	if pattern == "/" {
+		pattern = "/api"
+	}
 
-	route := &Route{
+	r.addRoute(&Route{
 		Pattern:   pattern,
 		Params:    params,
-		IsDynamic: isDynamic,
+		IsDynamic: len(params) > 0,
 		Type:      RouteTypeAPI,
 		File:      fullPath,
-		Method:    "*",
-	}
-
-	if isDynamic {
-		r.dynamic = append(r.dynamic, route)
-	} else {
-		r.static[pattern] = route
-	}
-	r.routes = append(r.routes, route)
-}
-
-func normalizePattern(pattern string) string {
-	pattern = regexp.MustCompile(`\{([^}]+)\}`).ReplaceAllString(pattern, "{$1}")
-	pattern = regexp.MustCompile(`\[([^\]]+)\]`).ReplaceAllString(pattern, "{$1}")
-	pattern = regexp.MustCompile(`_(\w+)`).ReplaceAllString(pattern, "{$1}")
-	pattern = regexp.MustCompile(`(\w+)_`).ReplaceAllString(pattern, "{$1}")
-	return pattern
+		Dir:       dir,
+		Methods:   methods,
+	})
+	return nil
 }
 
 func (r *Router) buildTree() {
@@ -242,84 +316,174 @@ func (r *Router) buildTree() {
 }
 
 func (r *Router) Lookup(path string) (*Route, map[string]string) {
-	if route, ok := r.static[path]; ok {
-		return route, nil
+	ps := AcquireParams()
+	defer ReleaseParams(ps)
+
+	route := r.Find(path, ps)
+	if route == nil {
+		return nil, nil
 	}
+	return route, ps.Map()
+}
 
-	return r.tree.lookup(path)
+// Find matches path against the route tree, appending captured params to
+// ps. It does not allocate when ps has enough capacity.
+func (r *Router) Find(path string, ps *Params) *Route {
+	if len(path) > 1 && path[len(path)-1] == '/' {
+		path = path[:len(path)-1]
+	}
+	if path == "" {
+		path = "/"
+	}
+
+	t := r.table.Load()
+	if route, ok := t.static[path]; ok {
+		return route
+	}
+
+	return t.tree.search(path, ps)
 }
 
-func (r *Router) Routes() []*Route {
-	sort.Slice(r.routes, func(i, j int) bool {
-		return r.routes[i].Pattern < r.routes[j].Pattern
-	})
-	return r.routes
+func (r *Router) Handle(pattern string, handler http.HandlerFunc) {
+	route := r.bind(pattern, RouteTypeAPI)
+	route.Handler = handler
+	if len(route.Methods) == 0 {
+		route.Methods = []string{AnyMethod}
+	}
 }
 
-func (r *Router) Layouts() []*Layout {
-	return r.layouts
+func (r *Router) HandleMethod(pattern, method string, handler http.HandlerFunc) {
+	route := r.bind(pattern, RouteTypeAPI)
+	if route.handlers == nil {
+		route.handlers = make(map[string]http.HandlerFunc)
+	}
+	route.handlers[method] = handler
+	if !route.hasMethod(method) {
+		route.Methods = append(route.Methods, method)
+	}
+}
+
+func (r *Router) HandlePage(pattern string, page PageFunc) {
+	r.bind(pattern, RouteTypePage).Page = page
+}
+
+func (r *Router) bind(pattern string, routeType RouteType) *Route {
+	for _, route := range r.routes {
+		if route.Pattern == pattern {
+			return route
+		}
+	}
+
+	route := &Route{
+		Pattern: pattern,
+		Type:    routeType,
+	}
+	if routeType == RouteTypePage {
+		route.Methods = []string{http.MethodGet}
+	}
+
+	if _, params, err := parsePath(pattern); err == nil {
+		route.Params = params
+		route.IsDynamic = len(params) > 0
+	}
+
+	r.addRoute(route)
+	r.tree.insert(pattern, route)
+
+	return route
 }
 
-func (r *Router) StaticRoutes() map[string]*Route {
-	return r.static
+// Routes returns a copy of the routes, sorted by pattern.
+func (r *Router) Routes() []*Route {
+	r.mu.RLock()
+	routes := slices.Clone(r.routes)
+	r.mu.RUnlock()
+
+	sort.Slice(routes, func(i, j int) bool {
+		return routes[i].Pattern < routes[j].Pattern
+	})
+	return routes
 }
 
-func (r *Router) DynamicRoutes() []*Route {
-	return r.dynamic
+func (r *Router) Layouts() []*Layout {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return slices.Clone(r.layouts)
 }
 
 func (r *Router) Mount(chiRouter interface {
 	Method(method, pattern string, handler http.Handler)
 }) {
-	for _, route := range r.static {
+	for _, route := range r.routes {
 		handler := r.createHandler(route)
-		if route.Type == RouteTypeAPI {
-			chiRouter.Method("GET", route.Pattern, handler)
-			chiRouter.Method("POST", route.Pattern, handler)
-			chiRouter.Method("PUT", route.Pattern, handler)
-			chiRouter.Method("DELETE", route.Pattern, handler)
-			chiRouter.Method("PATCH", route.Pattern, handler)
-		} else {
-			chiRouter.Method("GET", route.Pattern, handler)
+		methods := route.Methods
+		if len(methods) == 0 && route.Type == RouteTypePage {
+			methods = []string{http.MethodGet}
 		}
-	}
-
-	for _, route := range r.dynamic {
-		handler := r.createHandler(route)
-		if route.Type == RouteTypeAPI {
-			chiRouter.Method("GET", route.Pattern, handler)
-			chiRouter.Method("POST", route.Pattern, handler)
-			chiRouter.Method("PUT", route.Pattern, handler)
-			chiRouter.Method("DELETE", route.Pattern, handler)
-			chiRouter.Method("PATCH", route.Pattern, handler)
-		} else {
-			chiRouter.Method("GET", route.Pattern, handler)
+		for _, method := range methods {
+			if method == AnyMethod {
+				for _, m := range httpMethods {
+					chiRouter.Method(m, route.Pattern, handler)
+				}
+				continue
+			}
+			chiRouter.Method(method, route.Pattern, handler)
 		}
 	}
 }
 
 func (r *Router) createHandler(route *Route) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
-		ctx := req.Context()
-		ctx = context.WithValue(ctx, routeKey{}, route)
-		*req = *req.WithContext(ctx)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if !route.Allows(req.Method) {
+			w.Header().Set("Allow", strings.Join(route.Allow(), ", "))
+			if req.Method == http.MethodOptions {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+			return
+		}
 
-		if route.Handler != nil {
-			route.Handler(w, req)
+		if handler := route.MethodHandler(req.Method); handler != nil {
+			handler(w, req)
+			return
+		}
+
+		if route.Page != nil {
+			component, err := route.Component(req)
+			if err != nil {
+				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			component.Render(req.Context(), w)
 			return
 		}
 
 		r.defaultHandler(w, req, route)
 	})
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		ctx = context.WithValue(ctx, routeKey{}, route)
+		*req = *req.WithContext(ctx)
+
+		route.Wrap(handler).ServeHTTP(w, req)
+	})
 }
 
 func (r *Router) defaultHandler(w http.ResponseWriter, req *http.Request, route *Route) {
 	switch route.Type {
 	case RouteTypePage:
+		title := route.Metadata.Title
+		if title == "" {
+			title = "Zeptor - " + route.Pattern
+		}
+
 		w.Header().Set("Content-Type", "text/html; charset=utf-8")
 		fmt.Fprintf(w, `<!DOCTYPE html>
 <html>
-<head><title>Zeptor - %s</title>
+<head><title>%s</title>
 <script src="https://cdn.tailwindcss.com"></script>
 </head>
 <body class="bg-gray-900 text-white min-h-screen">
@@ -334,7 +498,7 @@ func (r *Router) defaultHandler(w http.ResponseWriter, req *http.Request, route
 <p class="text-yellow-400 mt-4">Handler not yet implemented</p>
 </main>
 </body>
-</html>`, route.Pattern, route.Pattern, route.Pattern, route.File)
+</html>`, html.EscapeString(title), route.Pattern, route.Pattern, route.File)
 
 	case RouteTypeAPI:
 		w.Header().Set("Content-Type", "application/json")
@@ -1,9 +1,22 @@
 package router
 
 import (
+	"context"
+	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/a-h/templ"
+
+	"github.com/brattlof/zeptor/internal/app/render"
 )
 
 func TestRouter_StaticRoutes(t *testing.T) {
@@ -32,12 +45,7 @@ func TestRouter_StaticRoutes(t *testing.T) {
 }
 
 func TestRouter_DynamicRoutes(t *testing.T) {
-	r := &Router{
-		static:  make(map[string]*Route),
-		dynamic: make([]*Route, 0),
-		routes:  make([]*Route, 0),
-		tree:    newRadixNode("", nodeStatic),
-	}
+	r := newRouter("")
 
 	route := &Route{
 		Pattern:   "/users/{id}",
@@ -46,7 +54,6 @@ func TestRouter_DynamicRoutes(t *testing.T) {
 		Type:      RouteTypePage,
 	}
 	r.tree.insert("/users/{id}", route)
-	r.dynamic = append(r.dynamic, route)
 
 	tests := []struct {
 		path       string
@@ -77,12 +84,7 @@ func TestRouter_DynamicRoutes(t *testing.T) {
 }
 
 func TestRouter_NestedDynamicRoutes(t *testing.T) {
-	r := &Router{
-		static:  make(map[string]*Route),
-		dynamic: make([]*Route, 0),
-		routes:  make([]*Route, 0),
-		tree:    newRadixNode("", nodeStatic),
-	}
+	r := newRouter("")
 
 	route1 := &Route{
 		Pattern:   "/users/{userId}/posts/{postId}",
@@ -160,27 +162,21 @@ func TestRadixTree_InsertAndLookup(t *testing.T) {
 }
 
 func TestRouter_Mount(t *testing.T) {
-	r := &Router{
-		static:  make(map[string]*Route),
-		dynamic: make([]*Route, 0),
-		routes:  make([]*Route, 0),
-		tree:    newRadixNode("", nodeStatic),
-	}
+	r := newRouter("")
 
 	r.static["/"] = &Route{Pattern: "/", Type: RouteTypePage}
 	r.static["/about"] = &Route{Pattern: "/about", Type: RouteTypePage}
-	r.dynamic = append(r.dynamic, &Route{
+
+	for _, route := range r.static {
+		r.routes = append(r.routes, route)
+	}
+	r.routes = append(r.routes, &Route{
 		Pattern:   "/users/{id}",
 		Params:    []string{"id"},
 		IsDynamic: true,
 		Type:      RouteTypePage,
 	})
 
-	for _, route := range r.static {
-		r.routes = append(r.routes, route)
-	}
-	r.routes = append(r.routes, r.dynamic...)
-
 	mux := http.NewServeMux()
 	routesMounted := 0
 
@@ -230,3 +226,1045 @@ func TestRouter_ParamsMiddleware(t *testing.T) {
 		t.Errorf("Expected status 200, got %d", rec.Code)
 	}
 }
+
+func TestRouter_APIMethods(t *testing.T) {
+	r, err := New("testdata/methods")
+	if err != nil {
+		t.Fatalf("Failed to create router: %v", err)
+	}
+
+	tests := []struct {
+		path      string
+		methods   []string
+		allow     string
+		allowsPut bool
+	}{
+		{"/api/items", []string{"GET", "POST"}, "GET, HEAD, POST, OPTIONS", false},
+		{"/api/legacy", []string{"*"}, "GET, HEAD, POST, PUT, PATCH, DELETE, OPTIONS", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			route, _ := r.Lookup(tt.path)
+			if route == nil {
+				t.Fatalf("Lookup(%q) = nil", tt.path)
+			}
+			if strings.Join(route.Methods, ",") != strings.Join(tt.methods, ",") {
+				t.Errorf("Methods = %v, want %v", route.Methods, tt.methods)
+			}
+			if got := strings.Join(route.Allow(), ", "); got != tt.allow {
+				t.Errorf("Allow() = %q, want %q", got, tt.allow)
+			}
+			if route.Allows("PUT") != tt.allowsPut {
+				t.Errorf("Allows(PUT) = %v, want %v", route.Allows("PUT"), tt.allowsPut)
+			}
+		})
+	}
+}
+
+func TestRouter_HandleMethod(t *testing.T) {
+	r, err := New("testdata/methods")
+	if err != nil {
+		t.Fatalf("Failed to create router: %v", err)
+	}
+
+	var called string
+	r.HandleMethod("/api/items", http.MethodGet, func(w http.ResponseWriter, req *http.Request) {
+		called = "GET"
+	})
+	r.HandleMethod("/api/items", http.MethodPost, func(w http.ResponseWriter, req *http.Request) {
+		called = "POST"
+	})
+
+	route, _ := r.Lookup("/api/items")
+	handler := r.createHandler(route)
+
+	tests := []struct {
+		method     string
+		wantStatus int
+		wantCalled string
+	}{
+		{http.MethodGet, http.StatusOK, "GET"},
+		{http.MethodHead, http.StatusOK, "GET"},
+		{http.MethodPost, http.StatusOK, "POST"},
+		{http.MethodDelete, http.StatusMethodNotAllowed, ""},
+		{http.MethodOptions, http.StatusNoContent, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			called = ""
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(tt.method, "/api/items", nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if called != tt.wantCalled {
+				t.Errorf("called = %q, want %q", called, tt.wantCalled)
+			}
+			if tt.wantStatus != http.StatusOK && rec.Header().Get("Allow") != "GET, HEAD, POST, OPTIONS" {
+				t.Errorf("Allow = %q", rec.Header().Get("Allow"))
+			}
+		})
+	}
+}
+
+func TestRouter_Layouts(t *testing.T) {
+	r, err := New("testdata/layouts")
+	if err != nil {
+		t.Fatalf("Failed to create router: %v", err)
+	}
+
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{"/", []string{"/"}},
+		{"/about", []string{"/"}},
+		{"/blog/{slug}", []string{"/", "/blog"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			var got []string
+			for _, l := range r.LayoutsFor(tt.pattern) {
+				got = append(got, l.Dir)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("LayoutsFor(%q) = %v, want %v", tt.pattern, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRouter_HandleLayout(t *testing.T) {
+	r, err := New("testdata/layouts")
+	if err != nil {
+		t.Fatalf("Failed to create router: %v", err)
+	}
+
+	wrap := func(tag string) LayoutFunc {
+		return func(req *http.Request) (render.Component, error) {
+			return templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+				io.WriteString(w, "<"+tag+">")
+				if err := templ.GetChildren(ctx).Render(ctx, w); err != nil {
+					return err
+				}
+				_, err := io.WriteString(w, "</"+tag+">")
+				return err
+			}), nil
+		}
+	}
+
+	r.HandleLayout("/", wrap("html"))
+	r.HandleLayout("/blog", wrap("section"))
+	r.HandlePage("/blog/{slug}", func(req *http.Request) (render.Component, error) {
+		return templ.Raw(Param(req, "slug")), nil
+	})
+
+	route, params := r.Lookup("/blog/hello")
+	if route == nil {
+		t.Fatal("Lookup(/blog/hello) = nil")
+	}
+
+	req := WithParams(httptest.NewRequest(http.MethodGet, "/blog/hello", nil), route, params)
+	rec := httptest.NewRecorder()
+	r.createHandler(route).ServeHTTP(rec, req)
+
+	if got, want := rec.Body.String(), "<html><section>hello</section></html>"; got != want {
+		t.Errorf("body = %q, want %q", got, want)
+	}
+}
+
+func TestParseSegment(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"about", "about", false},
+		{"my_page", "my_page", false},
+		{"slug_", "{slug}", false},
+		{"user_id_", "{user_id}", false},
+		{"id.int_", "{id:int}", false},
+		{"[id:uuid]", "{id:uuid}", false},
+		{"{slug:[a-z0-9-]+}", "{slug:[a-z0-9-]+}", false},
+		{"{code:[0-9]{3}}", "{code:[0-9]{3}}", false},
+		{"{bad:[a-z}", "", true},
+		{"id.int__", "", true},
+		{"[slug]", "{slug}", false},
+		{"{slug}", "{slug}", false},
+		{"slug__", "{...slug}", false},
+		{"[...slug]", "{...slug}", false},
+		{"slug___", "{...slug?}", false},
+		{"[[...slug]]", "{...slug?}", false},
+		{"(marketing)", "", false},
+		{"marketing.group", "", false},
+		{"_components", "", true},
+		{"[slug", "", true},
+		{"(a)(b)", "", true},
+		{"slug____", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, _, err := parsePath("/" + tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parsePath(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if err == nil && got != "/"+tt.want {
+				t.Errorf("parsePath(%q) = %q, want %q", tt.in, got, "/"+tt.want)
+			}
+		})
+	}
+}
+
+func TestRouter_Groups(t *testing.T) {
+	r, err := New("testdata/groups")
+	if err != nil {
+		t.Fatalf("Failed to create router: %v", err)
+	}
+
+	for _, path := range []string{"/about", "/cart", "/my_page"} {
+		if route, _ := r.Lookup(path); route == nil {
+			t.Errorf("Lookup(%q) = nil", path)
+		}
+	}
+
+	if route, _ := r.Lookup("/components"); route != nil {
+		t.Errorf("private folder was routed as %s", route.Pattern)
+	}
+
+	if got := len(r.LayoutsFor("/about")); got != 1 {
+		t.Errorf("LayoutsFor(/about) = %d layouts, want 1", got)
+	}
+	if got := len(r.LayoutsFor("/cart")); got != 0 {
+		t.Errorf("LayoutsFor(/cart) = %d layouts, want 0", got)
+	}
+}
+
+func TestRouter_CatchAll(t *testing.T) {
+	r, err := New("testdata/catchall")
+	if err != nil {
+		t.Fatalf("Failed to create router: %v", err)
+	}
+
+	tests := []struct {
+		path        string
+		wantPattern string
+		wantParam   string
+		wantSegs    []string
+	}{
+		{"/", "/", "", nil},
+		{"/docs", "/docs/{...slug?}", "", nil},
+		{"/docs/", "/docs/{...slug?}", "", nil},
+		{"/docs/intro", "/docs/{...slug?}", "intro", []string{"intro"}},
+		{"/docs/guide/routing", "/docs/{...slug?}", "guide/routing", []string{"guide", "routing"}},
+		{"/api/files/a/b.txt", "/api/files/{...path}", "a/b.txt", []string{"a", "b.txt"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			route, params := r.Lookup(tt.path)
+			if route == nil {
+				t.Fatalf("Lookup(%q) = nil", tt.path)
+			}
+			if route.Pattern != tt.wantPattern {
+				t.Fatalf("Lookup(%q) = %s, want %s", tt.path, route.Pattern, tt.wantPattern)
+			}
+
+			name := route.Params
+			if len(name) == 0 {
+				return
+			}
+			req := WithParams(httptest.NewRequest(http.MethodGet, tt.path, nil), route, params)
+			if got := Param(req, name[0]); got != tt.wantParam {
+				t.Errorf("Param(%q) = %q, want %q", name[0], got, tt.wantParam)
+			}
+			if got := ParamSegments(req, name[0]); strings.Join(got, ",") != strings.Join(tt.wantSegs, ",") {
+				t.Errorf("ParamSegments(%q) = %v, want %v", name[0], got, tt.wantSegs)
+			}
+		})
+	}
+
+	if route, _ := r.Lookup("/api/files"); route != nil {
+		t.Errorf("required catch-all matched its parent: %s", route.Pattern)
+	}
+}
+
+func TestRouter_TypedParams(t *testing.T) {
+	r, err := New("testdata/typed")
+	if err != nil {
+		t.Fatalf("Failed to create router: %v", err)
+	}
+
+	tests := []struct {
+		path        string
+		wantPattern string
+	}{
+		{"/users/42", "/users/{id:int}"},
+		{"/users/-7", "/users/{id:int}"},
+		{"/users/bob", "/users/{name}"},
+		{"/items/0b9e4c1a-3f0e-4c8e-9a57-2f1d8e6b7c10", "/items/{id:uuid}"},
+		{"/items/not-a-uuid", ""},
+		{"/tags/go-1", "/tags/{tag:[a-z0-9-]+}"},
+		{"/tags/Go", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			route, _ := r.Lookup(tt.path)
+			got := ""
+			if route != nil {
+				got = route.Pattern
+			}
+			if got != tt.wantPattern {
+				t.Errorf("Lookup(%q) = %q, want %q", tt.path, got, tt.wantPattern)
+			}
+		})
+	}
+
+	route, params := r.Lookup("/users/42")
+	req := WithParams(httptest.NewRequest(http.MethodGet, "/users/42", nil), route, params)
+	if id, err := ParamInt(req, "id"); err != nil || id != 42 {
+		t.Errorf("ParamInt(id) = %d, %v, want 42", id, err)
+	}
+	if _, err := ParamUUID(req, "id"); err == nil {
+		t.Error("ParamUUID(id) error = nil, want parse error")
+	}
+	if got := route.Constraints()["id"]; got != "int" {
+		t.Errorf("Constraints()[id] = %q, want int", got)
+	}
+}
+
+func TestWithParamList(t *testing.T) {
+	r := newBenchRouter(benchPatterns)
+	ps := AcquireParams()
+	defer ReleaseParams(ps)
+
+	route := r.Find("/blog/hello/comments/7", ps)
+	req := WithParamList(httptest.NewRequest(http.MethodGet, "/blog/hello/comments/7", nil), route, *ps)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		if got := Param(req, "comment"); got != "7" {
+			t.Errorf("Param(comment) = %q, want 7", got)
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("Param allocated %.0f times per run, want 0", allocs)
+	}
+
+	SetParams(req, map[string]string{"comment": "8"})
+	if got := GetParams(req); got["slug"] != "hello" || got["comment"] != "8" {
+		t.Errorf("GetParams() = %v, want slug hello and comment 8", got)
+	}
+}
+
+func writePages(t *testing.T, dirs ...string) string {
+	t.Helper()
+	root := t.TempDir()
+	for _, dir := range dirs {
+		path := filepath.Join(root, filepath.FromSlash(dir))
+		if err := os.MkdirAll(path, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(path, "page.templ"), []byte("package main\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestRouter_Diagnostics(t *testing.T) {
+	tests := []struct {
+		name     string
+		dirs     []string
+		wantKind string
+		wantErr  bool
+	}{
+		{"duplicate", []string{"(a)/about", "(b)/about"}, DiagnosticDuplicate, true},
+		{"param conflict", []string{"blog/slug_", "blog/[id]/edit"}, DiagnosticParamConflict, true},
+		{"invalid segment", []string{"blog/[slug"}, DiagnosticInvalidSegment, true},
+		{"static over param", []string{"about", "slug_"}, "", false},
+		{"page over optional catch-all", []string{"docs", "docs/slug___"}, "", false},
+		{"static over literal constraint", []string{"users/new", "users/[action:new]"}, DiagnosticShadowed, false},
+		{"constrained params", []string{"users/id.int_", "users/name_"}, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := New(writePages(t, tt.dirs...))
+
+			var diags Diagnostics
+			if tt.wantErr {
+				if !errors.As(err, &diags) {
+					t.Fatalf("New() error = %v, want Diagnostics", err)
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("New() error = %v", err)
+				}
+				diags = r.Diagnostics()
+			}
+
+			if tt.wantKind == "" {
+				if len(diags) != 0 {
+					t.Errorf("Diagnostics() = %v, want none", diags)
+				}
+				return
+			}
+			if len(diags) == 0 || diags[0].Kind != tt.wantKind {
+				t.Errorf("Diagnostics() = %v, want %s", diags, tt.wantKind)
+			}
+		})
+	}
+}
+
+func TestRouter_URL(t *testing.T) {
+	r := newBenchRouter([]string{
+		"/",
+		"/blog/{slug}",
+		"/users/{id:int}/posts",
+		"/files/{...path}",
+		"/docs/{...slug?}",
+	})
+
+	tests := []struct {
+		pattern string
+		params  []any
+		want    string
+		wantErr bool
+	}{
+		{"/", nil, "/", false},
+		{"/blog/{slug}", []any{"hello world"}, "/blog/hello%20world", false},
+		{"/blog/{slug}", []any{"a/b"}, "/blog/a%2Fb", false},
+		{"/blog/{slug}", nil, "", true},
+		{"/blog/{slug}", []any{"a", "b"}, "", true},
+		{"/users/{id:int}/posts", []any{42}, "/users/42/posts", false},
+		{"/users/{id:int}/posts", []any{"bob"}, "", true},
+		{"/users/{id:int}/posts", []any{uint8(7)}, "/users/7/posts", false},
+		{"/users/{id:int}/posts", []any{int32(-3)}, "/users/-3/posts", false},
+		{"/blog/{slug}", []any{true}, "/blog/true", false},
+		{"/files/{...path}", []any{[]string{"a b", "c.txt"}}, "/files/a%20b/c.txt", false},
+		{"/files/{...path}", []any{[]string{}}, "", true},
+		{"/docs/{...slug?}", nil, "/docs", false},
+		{"/docs/{...slug?}", []any{[]string{"guide"}}, "/docs/guide", false},
+		{"/missing", nil, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			got, err := r.URL(tt.pattern, tt.params...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("URL(%q, %v) error = %v, wantErr %v", tt.pattern, tt.params, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("URL(%q, %v) = %q, want %q", tt.pattern, tt.params, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEscapeParam(t *testing.T) {
+	if got := EscapeParam("slug", "", "a b"); got != "a%20b" {
+		t.Errorf("EscapeParam(slug, a b) = %q, want a%%20b", got)
+	}
+	if got := EscapeParam("code", "[a-z]+", "abc"); got != "abc" {
+		t.Errorf("EscapeParam(code, abc) = %q, want abc", got)
+	}
+
+	for name, fn := range map[string]func(){
+		"empty":     func() { EscapeParam("slug", "", "") },
+		"uuid":      func() { EscapeParam("id", "uuid", "bob") },
+		"regexp":    func() { EscapeParam("code", "[a-z]+", "ABC") },
+		"catch-all": func() { EscapeCatchAll("path", nil) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("did not panic")
+				}
+			}()
+			fn()
+		})
+	}
+}
+
+func TestRouter_Boundaries(t *testing.T) {
+	r, err := New("testdata/layouts")
+	if err != nil {
+		t.Fatalf("Failed to create router: %v", err)
+	}
+	if got := len(r.Boundaries()); got != 3 {
+		t.Fatalf("len(Boundaries()) = %d, want 3", got)
+	}
+
+	text := func(s string) PageFunc {
+		return func(req *http.Request) (render.Component, error) {
+			return templ.Raw(s), nil
+		}
+	}
+	r.HandleLayout("/blog", func(req *http.Request) (render.Component, error) {
+		return templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+			io.WriteString(w, "<section>")
+			templ.GetChildren(ctx).Render(ctx, w)
+			_, err := io.WriteString(w, "</section>")
+			return err
+		}), nil
+	})
+	r.HandleBoundary(BoundaryNotFound, "/", text("root"))
+	r.HandleBoundary(BoundaryNotFound, "/blog", text("blog"))
+	r.HandleBoundary(BoundaryError, "/blog", func(req *http.Request) (render.Component, error) {
+		return templ.Raw(RouteError(req).Error()), nil
+	})
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/missing", "/"},
+		{"/blog/hello/extra", "/blog"},
+		{"/blogs", "/"},
+	}
+	for _, tt := range tests {
+		b, _ := r.NotFoundFor(tt.path)
+		if b == nil || b.Dir != tt.want {
+			t.Errorf("NotFoundFor(%q) = %v, want %s", tt.path, b, tt.want)
+		}
+	}
+
+	if b := r.BoundaryFor(BoundaryError, "/about"); b != nil {
+		t.Errorf("BoundaryFor(error, /about) = %s, want nil", b.Dir)
+	}
+
+	b := r.BoundaryFor(BoundaryError, "/blog/slug_")
+	if b == nil {
+		t.Fatal("BoundaryFor(error, /blog/slug_) = nil")
+	}
+
+	req := WithError(httptest.NewRequest(http.MethodGet, "/blog/x", nil), errors.New("boom"))
+	component, err := r.BoundaryComponent(req, b)
+	if err != nil {
+		t.Fatalf("BoundaryComponent() error = %v", err)
+	}
+	var buf strings.Builder
+	component.Render(req.Context(), &buf)
+	if got, want := buf.String(), "<section>boom</section>"; got != want {
+		t.Errorf("error boundary = %q, want %q", got, want)
+	}
+}
+
+func TestRouter_Middleware(t *testing.T) {
+	root := writePages(t, "admin", "admin/users", "about")
+	if err := os.WriteFile(filepath.Join(root, "admin", "middleware.go"), []byte("package admin\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := New(root)
+	if err != nil {
+		t.Fatalf("Failed to create router: %v", err)
+	}
+	if got := len(r.Middlewares()); got != 1 {
+		t.Fatalf("len(Middlewares()) = %d, want 1", got)
+	}
+
+	tag := func(name string) MiddlewareFunc {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				io.WriteString(w, name+">")
+				next.ServeHTTP(w, req)
+			})
+		}
+	}
+	r.HandleMiddleware("/", tag("root"), "/admin/{...rest}", "/about")
+	r.HandleMiddleware("/admin", tag("admin"))
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/admin/users", "root>admin>page"},
+		{"/admin", "admin>page"},
+		{"/about", "root>page"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			route, _ := r.Lookup(tt.path)
+			if route == nil {
+				t.Fatalf("Lookup(%q) = nil", tt.path)
+			}
+
+			rec := httptest.NewRecorder()
+			route.Wrap(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				io.WriteString(w, "page")
+			})).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if got := rec.Body.String(); got != tt.want {
+				t.Errorf("body = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRouter_MiddlewareBuiltOnce(t *testing.T) {
+	r, err := New(writePages(t, "", "admin"))
+	if err != nil {
+		t.Fatalf("Failed to create router: %v", err)
+	}
+
+	built := 0
+	if err := r.HandleMiddleware("/admin", func(next http.Handler) http.Handler {
+		built++
+		return next
+	}); err != nil {
+		t.Fatalf("HandleMiddleware() error = %v", err)
+	}
+	if err := r.HandleMiddleware("/", nil, "no-slash"); err == nil {
+		t.Error("HandleMiddleware() with an invalid matcher error = nil")
+	}
+
+	route, _ := r.Lookup("/admin")
+	served := 0
+	for i := 0; i < 3; i++ {
+		route.Wrap(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			served++
+		})).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/admin", nil))
+	}
+	if built != 1 || served != 3 {
+		t.Errorf("constructor calls, requests served = %d, %d, want 1, 3", built, served)
+	}
+}
+
+func TestRouter_Metadata(t *testing.T) {
+	root := writePages(t, "", "blog/slug_")
+	writeFile := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(root, filepath.FromSlash(name)), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile("meta.yaml", "title: Zeptor\ntitleTemplate: \"%s | Zeptor\"\n")
+	writeFile("blog/meta.yaml", "description: Posts\nopenGraph:\n  type: article\n")
+	writeFile("blog/slug_/page.go", "package slug_\n\nvar Metadata = render.Metadata{Title: \"Post\", CacheControl: \"max-age=60\"}\n")
+
+	r, err := New(root)
+	if err != nil {
+		t.Fatalf("Failed to create router: %v", err)
+	}
+
+	route, _ := r.Lookup("/blog/hello")
+	if route == nil {
+		t.Fatal("Lookup(/blog/hello) = nil")
+	}
+
+	want := render.Metadata{
+		Title:         "Post | Zeptor",
+		TitleTemplate: "%s | Zeptor",
+		Description:   "Posts",
+		CacheControl:  "max-age=60",
+		OpenGraph:     render.OpenGraph{Type: "article"},
+	}
+	if !reflect.DeepEqual(route.Metadata, want) {
+		t.Errorf("Metadata = %+v, want %+v", route.Metadata, want)
+	}
+
+	r.HandleMetadata("/blog/slug_", render.Metadata{Title: "Registered"})
+	if got := route.Metadata.Title; got != "Registered | Zeptor" {
+		t.Errorf("Metadata.Title after HandleMetadata = %q, want %q", got, "Registered | Zeptor")
+	}
+
+	if home, _ := r.Lookup("/"); home.Metadata.Title != "Zeptor" {
+		t.Errorf("home Metadata.Title = %q, want Zeptor", home.Metadata.Title)
+	}
+}
+
+func TestRouter_Hosts(t *testing.T) {
+	r, err := New(writePages(t, ".", "about", "tenants/dashboard"))
+	if err != nil {
+		t.Fatalf("Failed to create router: %v", err)
+	}
+	docs := writePages(t, ".", "guide")
+
+	if err := r.AddHost("docs.example.com", docs, ""); err != nil {
+		t.Fatalf("AddHost(docs) error = %v", err)
+	}
+	if err := r.AddHost("{tenant:[a-z]+}.example.com", "", "/tenants"); err != nil {
+		t.Fatalf("AddHost(tenant) error = %v", err)
+	}
+	for _, pattern := range []string{"*.example.com", "a..com", "{...rest}.example.com"} {
+		if err := r.AddHost(pattern, "", "/tenants"); err == nil {
+			t.Errorf("AddHost(%q) error = nil, want error", pattern)
+		}
+	}
+	if r.Host("docs.example.com") == nil {
+		t.Fatal("Host(docs.example.com) = nil")
+	}
+
+	tests := []struct {
+		host       string
+		path       string
+		wantRoute  string
+		wantTenant string
+	}{
+		{"docs.example.com", "/guide", "/guide", ""},
+		{"DOCS.example.com:8080", "/", "/", ""},
+		{"acme.example.com", "/dashboard", "/tenants/dashboard", "acme"},
+		{"acme.example.com", "/dashboard/", "/tenants/dashboard", "acme"},
+		{"example.com", "/about", "/about", ""},
+		{"acme1.example.com", "/about", "/about", ""},
+		{"docs.example.com", "/about", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host+tt.path, func(t *testing.T) {
+			route, params := r.LookupHost(tt.host, tt.path)
+			if tt.wantRoute == "" {
+				if route != nil {
+					t.Errorf("LookupHost() = %s, want nil", route.Pattern)
+				}
+				return
+			}
+			if route == nil {
+				t.Fatalf("LookupHost() = nil, want %s", tt.wantRoute)
+			}
+			if route.Pattern != tt.wantRoute {
+				t.Errorf("Pattern = %s, want %s", route.Pattern, tt.wantRoute)
+			}
+			if params["tenant"] != tt.wantTenant {
+				t.Errorf("params[tenant] = %q, want %q", params["tenant"], tt.wantTenant)
+			}
+		})
+	}
+}
+
+func TestRouter_Reload(t *testing.T) {
+	root := writePages(t, ".", "about", "blog")
+	r, err := New(root)
+	if err != nil {
+		t.Fatalf("Failed to create router: %v", err)
+	}
+
+	page := func(req *http.Request) (render.Component, error) { return templ.NopComponent, nil }
+	r.HandlePage("/about", page)
+	r.Handle("/custom", func(w http.ResponseWriter, req *http.Request) {})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 1000; i++ {
+			if route, _ := r.Lookup("/"); route == nil {
+				t.Error("Lookup(/) = nil during reload")
+				return
+			}
+			if _, err := r.URL("/about"); err != nil || len(r.Routes()) == 0 {
+				t.Errorf("URL(/about) error = %v during reload", err)
+				return
+			}
+			r.Layouts()
+		}
+	}()
+
+	if err := os.RemoveAll(filepath.Join(root, "blog")); err != nil {
+		t.Fatal(err)
+	}
+	writeFile := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile("contact/page.templ", "package contact\n")
+	writeFile("about/layout.templ", "package about\n")
+
+	diff, err := r.Reload()
+	<-done
+	if err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+
+	if got := strings.Join(diff.Added, ","); got != "/contact" {
+		t.Errorf("Added = %v, want [/contact]", diff.Added)
+	}
+	if got := strings.Join(diff.Removed, ","); got != "/blog" {
+		t.Errorf("Removed = %v, want [/blog]", diff.Removed)
+	}
+	if got := strings.Join(diff.Changed, ","); got != "/about" {
+		t.Errorf("Changed = %v, want [/about]", diff.Changed)
+	}
+
+	if route, _ := r.Lookup("/blog"); route != nil {
+		t.Error("Lookup(/blog) found a removed route")
+	}
+	if route, _ := r.Lookup("/about"); route == nil || route.Page == nil || len(route.Layouts) != 1 {
+		t.Errorf("Lookup(/about) = %+v, want the registered page inside its new layout", route)
+	}
+	if route, _ := r.Lookup("/custom"); route == nil || route.Handler == nil {
+		t.Error("Lookup(/custom) lost the handler registered without a file")
+	}
+
+	writeFile("contact/[id]/page.templ", "package id\n")
+	writeFile("contact/id_/page.templ", "package id_\n")
+	if _, err := r.Reload(); err == nil {
+		t.Error("Reload() error = nil, want duplicate route error")
+	}
+	if route, _ := r.Lookup("/contact"); route == nil {
+		t.Error("failed Reload() replaced the routes")
+	}
+}
+
+func TestRouter_ReloadHostFailure(t *testing.T) {
+	root := writePages(t, ".", "about")
+	r, err := New(root)
+	if err != nil {
+		t.Fatalf("Failed to create router: %v", err)
+	}
+	docs := writePages(t, ".", "guide")
+	blog := writePages(t, ".", "posts")
+	if err := r.AddHost("docs.example.com", docs, ""); err != nil {
+		t.Fatalf("AddHost(docs) error = %v", err)
+	}
+	if err := r.AddHost("blog.example.com", blog, ""); err != nil {
+		t.Fatalf("AddHost(blog) error = %v", err)
+	}
+
+	for _, name := range []string{
+		filepath.Join(root, "contact/page.templ"),
+		filepath.Join(docs, "faq/page.templ"),
+		filepath.Join(blog, "posts/[id]/page.templ"),
+		filepath.Join(blog, "posts/id_/page.templ"),
+	} {
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(name, []byte("package p\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := r.Reload(); err == nil {
+		t.Fatal("Reload() error = nil, want duplicate route error from blog host")
+	}
+	if route, _ := r.Lookup("/contact"); route != nil {
+		t.Error("failed Reload() swapped the app's routes")
+	}
+	if route, _ := r.LookupHost("docs.example.com", "/faq"); route != nil {
+		t.Error("failed Reload() swapped the routes of a host before the failing one")
+	}
+}
+
+func TestNewFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"page.templ":             {Data: []byte("package app\n")},
+		"layout.templ":           {Data: []byte("package app\n")},
+		"meta.yaml":              {Data: []byte("title: Home\n")},
+		"blog/slug_/page.templ":  {Data: []byte("package slug_\n")},
+		"api/users/route.go":     {Data: []byte("package users\n\nimport \"net/http\"\n\nfunc GET(w http.ResponseWriter, r *http.Request) {}\n")},
+		"_components/page.templ": {Data: []byte("package components\n")},
+	}
+
+	r, err := NewFS(fsys)
+	if err != nil {
+		t.Fatalf("NewFS() error = %v", err)
+	}
+
+	var patterns []string
+	for _, route := range r.Routes() {
+		patterns = append(patterns, route.Pattern)
+	}
+	if got := strings.Join(patterns, " "); got != "/ /api/users /blog/{slug}" {
+		t.Errorf("patterns = %s, want / /api/users /blog/{slug}", got)
+	}
+
+	route, params := r.Lookup("/blog/hello")
+	if route == nil || params["slug"] != "hello" {
+		t.Fatalf("Lookup(/blog/hello) = %v, %v", route, params)
+	}
+	if route.File != "blog/slug_/page.templ" || len(route.Layouts) != 1 {
+		t.Errorf("File, layouts = %s, %d, want blog/slug_/page.templ, 1", route.File, len(route.Layouts))
+	}
+	if users, _ := r.Lookup("/api/users"); users == nil || !users.Allows(http.MethodGet) {
+		t.Error("Lookup(/api/users) did not find the GET handler")
+	}
+	if home, _ := r.Lookup("/"); home.Metadata.Title != "Home" {
+		t.Errorf("Metadata.Title = %q, want Home", home.Metadata.Title)
+	}
+}
+
+func TestRouter_Manifest(t *testing.T) {
+	discovered, err := New("testdata/layouts")
+	if err != nil {
+		t.Fatalf("Failed to create router: %v", err)
+	}
+
+	var buf strings.Builder
+	if err := discovered.WriteManifest(&buf); err != nil {
+		t.Fatalf("WriteManifest() error = %v", err)
+	}
+
+	r, err := LoadManifest(fstest.MapFS{ManifestFile: {Data: []byte(buf.String())}})
+	if err != nil {
+		t.Fatalf("LoadManifest() error = %v", err)
+	}
+
+	if got, want := len(r.Routes()), len(discovered.Routes()); got != want {
+		t.Errorf("len(Routes()) = %d, want %d", got, want)
+	}
+	route, params := r.Lookup("/blog/hello")
+	if route == nil || params["slug"] != "hello" {
+		t.Fatalf("Lookup(/blog/hello) = %v, %v", route, params)
+	}
+	if route.File != "blog/slug_/page.templ" {
+		t.Errorf("File = %s, want blog/slug_/page.templ", route.File)
+	}
+	if got := layoutDirs(route.Layouts); strings.Join(got, " ") != "/ /blog" {
+		t.Errorf("layouts = %v, want [/ /blog]", got)
+	}
+	if b := r.BoundaryFor(BoundaryError, route.Dir); b != nil {
+		t.Errorf("BoundaryFor() = %v before a component is registered", b)
+	}
+	if len(r.Boundaries()) != 3 {
+		t.Errorf("len(Boundaries()) = %d, want 3", len(r.Boundaries()))
+	}
+
+	if _, err := r.Reload(); err == nil {
+		t.Error("Reload() error = nil, want error for a manifest router")
+	}
+
+	_, err = FromManifest(&Manifest{Version: ManifestVersion + 1})
+	if err == nil {
+		t.Error("FromManifest() error = nil, want version error")
+	}
+}
+
+func TestRouter_ManifestHosts(t *testing.T) {
+	discovered, err := New(writePages(t, ".", "about", "tenants/dashboard"))
+	if err != nil {
+		t.Fatalf("Failed to create router: %v", err)
+	}
+	if err := discovered.AddHost("docs.example.com", writePages(t, ".", "guide"), ""); err != nil {
+		t.Fatalf("AddHost(docs) error = %v", err)
+	}
+	if err := discovered.AddHost("{tenant}.example.com", "", "/tenants"); err != nil {
+		t.Fatalf("AddHost(tenant) error = %v", err)
+	}
+
+	var buf strings.Builder
+	if err := discovered.WriteManifest(&buf); err != nil {
+		t.Fatalf("WriteManifest() error = %v", err)
+	}
+	r, err := LoadManifest(fstest.MapFS{ManifestFile: {Data: []byte(buf.String())}})
+	if err != nil {
+		t.Fatalf("LoadManifest() error = %v", err)
+	}
+
+	if r.Host("docs.example.com") == nil {
+		t.Fatal("Host(docs.example.com) = nil")
+	}
+	if route, _ := r.LookupHost("docs.example.com", "/guide"); route == nil {
+		t.Error("LookupHost(docs.example.com, /guide) = nil")
+	}
+	route, params := r.LookupHost("acme.example.com", "/dashboard")
+	if route == nil || route.Pattern != "/tenants/dashboard" || params["tenant"] != "acme" {
+		t.Errorf("LookupHost(acme.example.com, /dashboard) = %v, %v", route, params)
+	}
+}
+
+func TestRoute_Load(t *testing.T) {
+	r, err := NewFS(fstest.MapFS{
+		"layout.templ":            {Data: []byte("package app\n")},
+		"blog/slug_/page.templ":   {Data: []byte("package slug_\n")},
+		"blog/slug_/layout.templ": {Data: []byte("package slug_\n")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Each loader waits for the other, so they only finish when run in
+	// parallel.
+	started := make(chan struct{}, 2)
+	wait := func() {
+		started <- struct{}{}
+		for len(started) < 2 {
+			time.Sleep(time.Millisecond)
+		}
+	}
+	r.HandleLayoutLoad("/", func(ctx context.Context, req *http.Request) (any, error) {
+		wait()
+		return []string{"home", "blog"}, nil
+	})
+	r.HandleLoad("/blog/{slug}", func(ctx context.Context, req *http.Request) (any, error) {
+		wait()
+		switch slug := Param(req, "slug"); slug {
+		case "missing":
+			return nil, NotFound()
+		case "old":
+			return nil, Redirect("/blog/new")
+		default:
+			return "post " + slug, nil
+		}
+	})
+	load := func(ctx context.Context, req *http.Request) (string, error) { return "", nil }
+	nav := func(ctx context.Context, req *http.Request) ([]string, error) { return nil, nil }
+	r.HandleLayout("/", func(req *http.Request) (render.Component, error) {
+		return templ.Raw(strings.Join(LayoutProps(req, "/", nav), ",") + ":"), nil
+	})
+	r.HandlePage("/blog/{slug}", func(req *http.Request) (render.Component, error) {
+		return templ.Raw(PageProps(req, load)), nil
+	})
+
+	route, params := r.Lookup("/blog/hello")
+	req := WithParams(httptest.NewRequest(http.MethodGet, "/blog/hello", nil), route, params)
+	req, err = route.Load(req)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := PageProps(req, load); got != "post hello" {
+		t.Errorf("PageProps() = %q, want %q", got, "post hello")
+	}
+	if got := LayoutProps(req, "/", nav); !reflect.DeepEqual(got, []string{"home", "blog"}) {
+		t.Errorf("LayoutProps() = %v", got)
+	}
+
+	for slug, check := range map[string]func(error) bool{
+		"missing": func(err error) bool { return errors.Is(err, ErrNotFound) },
+		"old": func(err error) bool {
+			var redirect *RedirectError
+			return errors.As(err, &redirect) && redirect.URL == "/blog/new" && redirect.Status == http.StatusTemporaryRedirect
+		},
+	} {
+		for len(started) > 0 {
+			<-started
+		}
+		req := WithParams(httptest.NewRequest(http.MethodGet, "/blog/"+slug, nil), route, map[string]string{"slug": slug})
+		if _, err := route.Component(req); !check(err) {
+			t.Errorf("Component(/blog/%s) error = %v", slug, err)
+		}
+	}
+}
+
+func TestRouter_HandleBoundarySorted(t *testing.T) {
+	r := newRouter("")
+	for _, dir := range []string{"/blog/slug_", "/", "/blog", "/docs"} {
+		r.HandleBoundary(BoundaryNotFound, dir, func(req *http.Request) (render.Component, error) {
+			return templ.Raw(dir), nil
+		})
+	}
+
+	var got []string
+	for _, b := range r.Boundaries() {
+		got = append(got, b.Dir)
+	}
+	if want := []string{"/", "/blog", "/docs", "/blog/slug_"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Boundaries() = %v, want %v", got, want)
+	}
+
+	r.Boundaries()[0] = nil
+	if r.Boundaries()[0] == nil {
+		t.Error("Boundaries() returned the router's own slice")
+	}
+}
@@ -0,0 +1,154 @@
+package router
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Directory names are parsed one segment at a time. Every form has a
+// Go-friendly spelling so page packages stay importable:
+//
+//	about                       static       /about
+//	slug_    [slug]             param        /{slug}
+//	id.int_  [id:int]           typed param  /{id:int}
+//	         {slug:[a-z0-9-]+}  constrained  /{slug:[a-z0-9-]+}
+//	slug__   [...slug]          catch-all    /{...slug}
+//	slug___  [[...slug]]        optional     /{...slug?}, also matches the parent
+//	marketing.group (marketing) group        (no URL segment)
+//	_components                 private      (not routed)
+type segmentKind int
+
+const (
+	segmentStatic segmentKind = iota
+	segmentParam
+	segmentCatchAll
+	segmentOptionalCatchAll
+	segmentGroup
+	segmentPrivate
+)
+
+type segment struct {
+	kind       segmentKind
+	name       string
+	constraint string
+}
+
+var (
+	suffixSegment   = regexp.MustCompile(`^([A-Za-z](?:\w*[A-Za-z0-9])?)(?:\.(int|uuid))?(_{1,3})$`)
+	paramSegment    = regexp.MustCompile(`^(?:\[([A-Za-z]\w*)(?::([^/]+))?\]|\{([A-Za-z]\w*)(?::([^/]+))?\})$`)
+	catchAllSegment = regexp.MustCompile(`^(?:\[\.\.\.([A-Za-z]\w*)\]|\{\.\.\.([A-Za-z]\w*)\})$`)
+	optionalSegment = regexp.MustCompile(`^(?:\[\[\.\.\.([A-Za-z]\w*)\]\]|\{\.\.\.([A-Za-z]\w*)\?\})$`)
+	groupSegment    = regexp.MustCompile(`^(?:\(([A-Za-z0-9][\w-]*)\)|([A-Za-z0-9][\w-]*)\.group)$`)
+	reservedSegment = regexp.MustCompile(`[\[\]{}()]`)
+)
+
+func parseSegment(s string) (segment, error) {
+	if strings.HasPrefix(s, "_") {
+		return segment{kind: segmentPrivate, name: s[1:]}, nil
+	}
+
+	if m := groupSegment.FindStringSubmatch(s); m != nil {
+		return segment{kind: segmentGroup, name: m[1] + m[2]}, nil
+	}
+
+	if m := optionalSegment.FindStringSubmatch(s); m != nil {
+		return segment{kind: segmentOptionalCatchAll, name: m[1] + m[2]}, nil
+	}
+
+	if m := catchAllSegment.FindStringSubmatch(s); m != nil {
+		return segment{kind: segmentCatchAll, name: m[1] + m[2]}, nil
+	}
+
+	if m := paramSegment.FindStringSubmatch(s); m != nil {
+		seg := segment{kind: segmentParam, name: m[1] + m[3], constraint: m[2] + m[4]}
+		if _, err := compileConstraint(seg.constraint); err != nil {
+			return segment{}, fmt.Errorf("route segment %q: %w", s, err)
+		}
+		return seg, nil
+	}
+
+	if m := suffixSegment.FindStringSubmatch(s); m != nil {
+		switch {
+		case m[2] != "" && len(m[3]) > 1:
+			return segment{}, fmt.Errorf("invalid route segment %q: catch-alls cannot be typed", s)
+		case len(m[3]) == 3:
+			return segment{kind: segmentOptionalCatchAll, name: m[1]}, nil
+		case len(m[3]) == 2:
+			return segment{kind: segmentCatchAll, name: m[1]}, nil
+		}
+		return segment{kind: segmentParam, name: m[1], constraint: m[2]}, nil
+	}
+
+	if s == "" || reservedSegment.MatchString(s) || strings.HasSuffix(s, "_") {
+		return segment{}, fmt.Errorf("invalid route segment %q", s)
+	}
+
+	return segment{kind: segmentStatic, name: s}, nil
+}
+
+func (s segment) String() string {
+	switch s.kind {
+	case segmentParam:
+		if s.constraint != "" {
+			return "{" + s.name + ":" + s.constraint + "}"
+		}
+		return "{" + s.name + "}"
+	case segmentCatchAll:
+		return "{..." + s.name + "}"
+	case segmentOptionalCatchAll:
+		return "{..." + s.name + "?}"
+	default:
+		return s.name
+	}
+}
+
+// parsePath turns a slash-separated app directory (or route pattern) into
+// a URL pattern and the names of its params.
+func parsePath(dir string) (string, []string, error) {
+	var b strings.Builder
+	params := []string{}
+
+	for _, part := range strings.Split(strings.Trim(dir, "/"), "/") {
+		if part == "" {
+			continue
+		}
+
+		seg, err := parseSegment(part)
+		if err != nil {
+			return "", nil, err
+		}
+
+		switch seg.kind {
+		case segmentGroup:
+			continue
+		case segmentPrivate:
+			return "", nil, fmt.Errorf("private segment %q cannot be routed", part)
+		case segmentParam, segmentCatchAll, segmentOptionalCatchAll:
+			params = append(params, seg.name)
+		}
+
+		b.WriteString("/")
+		b.WriteString(seg.String())
+	}
+
+	if b.Len() == 0 {
+		return "/", params, nil
+	}
+	return b.String(), params, nil
+}
+
+func patternConstraints(pattern string) map[string]string {
+	var constraints map[string]string
+	for _, part := range strings.Split(pattern, "/") {
+		seg, err := parseSegment(part)
+		if err != nil || seg.constraint == "" {
+			continue
+		}
+		if constraints == nil {
+			constraints = make(map[string]string)
+		}
+		constraints[seg.name] = seg.constraint
+	}
+	return constraints
+}
@@ -0,0 +1,5 @@
+package path__
+
+import "net/http"
+
+func GET(w http.ResponseWriter, r *http.Request) {}
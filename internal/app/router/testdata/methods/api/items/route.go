@@ -0,0 +1,9 @@
+package items
+
+import "net/http"
+
+func GET(w http.ResponseWriter, r *http.Request) {}
+
+func POST(w http.ResponseWriter, r *http.Request) {}
+
+func helper() {}
@@ -0,0 +1,5 @@
+package legacy
+
+import "net/http"
+
+func Handler(w http.ResponseWriter, r *http.Request) {}
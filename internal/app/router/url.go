@@ -0,0 +1,182 @@
+package router
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// URL builds a path for a registered route pattern. Params are given in
+// pattern order; catch-alls take a []string.
+func (r *Router) URL(pattern string, params ...any) (string, error) {
+	r.mu.RLock()
+	known := slices.ContainsFunc(r.routes, func(route *Route) bool {
+		return route.Pattern == pattern
+	})
+	r.mu.RUnlock()
+
+	if !known {
+		return "", fmt.Errorf("unknown route %s", pattern)
+	}
+	return BuildURL(pattern, params...)
+}
+
+// BuildURL is URL without the registration check.
+func BuildURL(pattern string, params ...any) (string, error) {
+	var b strings.Builder
+	used := 0
+
+	for _, seg := range patternSegments(pattern) {
+		switch seg.kind {
+		case segmentParam:
+			if used >= len(params) {
+				return "", fmt.Errorf("%s: missing value for %s", pattern, seg.name)
+			}
+			value, err := urlValue(params[used])
+			if err != nil {
+				return "", fmt.Errorf("%s: param %s: %w", pattern, seg.name, err)
+			}
+			used++
+
+			if match, _ := compileConstraint(seg.constraint); value == "" || match != nil && !match(value) {
+				return "", fmt.Errorf("%s: param %s: %q does not satisfy %s", pattern, seg.name, value, seg.String())
+			}
+			b.WriteString("/")
+			b.WriteString(url.PathEscape(value))
+
+		case segmentCatchAll, segmentOptionalCatchAll:
+			var values []string
+			if used < len(params) {
+				var err error
+				if values, err = urlSegments(params[used]); err != nil {
+					return "", fmt.Errorf("%s: param %s: %w", pattern, seg.name, err)
+				}
+				used++
+			}
+			if len(values) == 0 && seg.kind == segmentCatchAll {
+				return "", fmt.Errorf("%s: missing value for %s", pattern, seg.name)
+			}
+			b.WriteString(EscapeSegments(values))
+
+		default:
+			b.WriteString("/")
+			b.WriteString(seg.name)
+		}
+	}
+
+	if used < len(params) {
+		return "", fmt.Errorf("%s: %d unexpected param value(s)", pattern, len(params)-used)
+	}
+	if b.Len() == 0 {
+		return "/", nil
+	}
+	return b.String(), nil
+}
+
+// EscapeParam escapes value for the param name with constraint, for URL
+// helpers generated by zt generate routes. A value the route would not
+// match is a bug in the link, so it panics.
+func EscapeParam(name, constraint, value string) string {
+	match, err := compileConstraint(constraint)
+	if err != nil {
+		panic(fmt.Sprintf("param %s: %v", name, err))
+	}
+	if value == "" || match != nil && !match(value) {
+		seg := segment{kind: segmentParam, name: name, constraint: constraint}
+		panic(fmt.Sprintf("param %s: %q does not satisfy %s", name, value, seg))
+	}
+	return url.PathEscape(value)
+}
+
+// EscapeCatchAll is EscapeSegments for a required catch-all, which panics
+// without segments.
+func EscapeCatchAll(name string, segments []string) string {
+	if len(segments) == 0 {
+		panic(fmt.Sprintf("param %s: missing value for {...%s}", name, name))
+	}
+	return EscapeSegments(segments)
+}
+
+// EscapeSegments escapes each catch-all segment and joins them, with a
+// leading slash, for appending to a parent path.
+func EscapeSegments(segments []string) string {
+	var b strings.Builder
+	for _, s := range segments {
+		b.WriteString("/")
+		b.WriteString(url.PathEscape(s))
+	}
+	return b.String()
+}
+
+func urlValue(v any) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int, int8, int16, int32, int64:
+		return strconv.FormatInt(reflect.ValueOf(v).Int(), 10), nil
+	case uint, uint8, uint16, uint32, uint64, uintptr:
+		return strconv.FormatUint(reflect.ValueOf(v).Uint(), 10), nil
+	case fmt.Stringer:
+		return v.String(), nil
+	default:
+		return "", fmt.Errorf("unsupported value type %T", v)
+	}
+}
+
+func urlSegments(v any) ([]string, error) {
+	switch v := v.(type) {
+	case []string:
+		return v, nil
+	case string:
+		if v == "" {
+			return nil, nil
+		}
+		return strings.Split(strings.Trim(v, "/"), "/"), nil
+	default:
+		return nil, fmt.Errorf("unsupported catch-all type %T", v)
+	}
+}
+
+// StaticParamsFunc lists the param sets a dynamic page is pre-rendered
+// with by zt build --ssg, e.g. [{"slug": "hello"}]. Catch-all values are
+// slash-separated.
+type StaticParamsFunc func() ([]map[string]string, error)
+
+func (r *Router) HandleStaticParams(pattern string, fn StaticParamsFunc) {
+	r.bind(pattern, RouteTypePage).StaticParams = fn
+}
+
+// StaticPaths returns the paths a page can be pre-rendered at: its pattern
+// when it has no params, otherwise one path per StaticParams entry.
+func (r *Route) StaticPaths() ([]string, error) {
+	if !r.IsDynamic {
+		return []string{r.Pattern}, nil
+	}
+	if r.StaticParams == nil {
+		return nil, nil
+	}
+
+	sets, err := r.StaticParams()
+	if err != nil {
+		return nil, fmt.Errorf("%s: StaticParams: %w", r.Pattern, err)
+	}
+
+	paths := make([]string, 0, len(sets))
+	for _, set := range sets {
+		values := make([]any, len(r.Params))
+		for i, name := range r.Params {
+			values[i] = set[name]
+		}
+		p, err := BuildURL(r.Pattern, values...)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, p)
+	}
+	return paths, nil
+}
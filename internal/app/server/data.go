@@ -0,0 +1,147 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/brattlof/zeptor/internal/app/router"
+)
+
+// DataQuery asks a page with a loader for its props as JSON, the same as
+// an Accept header preferring application/json.
+const DataQuery = "_data"
+
+// wantsData reports whether r asks for a page's props rather than its
+// HTML. Browsers list */* after text/html, so they get the page. On a tie,
+// JSON listed before */* without text/html, as axios sends it, wins.
+func wantsData(r *http.Request) bool {
+	if _, ok := r.URL.Query()[DataQuery]; ok {
+		return true
+	}
+	accept := r.Header.Get("Accept")
+	if accept == "" {
+		return false
+	}
+
+	ranges := parseAccept(accept)
+	jsonQ, htmlQ := acceptQuality(ranges, "application/json"), acceptQuality(ranges, "text/html")
+	if jsonQ != htmlQ || jsonQ == 0 {
+		return jsonQ > htmlQ
+	}
+	i := acceptIndex(ranges, "application/json")
+	wildcard := acceptIndex(ranges, "*/*")
+	return i >= 0 && acceptIndex(ranges, "text/html") < 0 && (wildcard < 0 || i < wildcard)
+}
+
+// acceptsHTML reports whether r takes an HTML error or not-found page.
+func acceptsHTML(r *http.Request) bool {
+	accept := r.Header.Get("Accept")
+	return accept == "" || acceptQuality(parseAccept(accept), "text/html") > 0
+}
+
+type mediaRange struct {
+	mediaType string
+	q         float64
+}
+
+// parseAccept splits an Accept header into its media ranges, in order.
+func parseAccept(accept string) []mediaRange {
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		params := strings.Split(part, ";")
+		mr := mediaRange{mediaType: strings.ToLower(strings.TrimSpace(params[0])), q: 1}
+		for _, p := range params[1:] {
+			if v, ok := strings.CutPrefix(strings.TrimSpace(p), "q="); ok {
+				if f, err := strconv.ParseFloat(v, 64); err == nil {
+					mr.q = f
+				}
+			}
+		}
+		ranges = append(ranges, mr)
+	}
+	return ranges
+}
+
+// acceptQuality returns the q value ranges give mediaType, taken from the
+// most specific range that matches it.
+func acceptQuality(ranges []mediaRange, mediaType string) float64 {
+	typ, _, _ := strings.Cut(mediaType, "/")
+	quality, specificity := 0.0, -1
+
+	for _, mr := range ranges {
+		s := -1
+		switch mr.mediaType {
+		case mediaType:
+			s = 2
+		case typ + "/*":
+			s = 1
+		case "*/*":
+			s = 0
+		default:
+			continue
+		}
+		if s > specificity {
+			quality, specificity = mr.q, s
+		}
+	}
+	return quality
+}
+
+// acceptIndex returns the position of mediaType in ranges, or -1.
+func acceptIndex(ranges []mediaRange, mediaType string) int {
+	for i, mr := range ranges {
+		if mr.mediaType == mediaType {
+			return i
+		}
+	}
+	return -1
+}
+
+// serveData answers r with the props of the page's loader as JSON. Pages
+// that are not found get a JSON 404, and redirects stay JSON requests.
+func (s *Server) serveData(w http.ResponseWriter, r *http.Request, route *router.Route) {
+	props, err := route.Data(r)
+	var body []byte
+	if err == nil {
+		body, err = json.Marshal(props)
+	}
+
+	var redirect *router.RedirectError
+	switch {
+	case errors.As(err, &redirect):
+		http.Redirect(w, r, dataURL(r, redirect.URL), redirect.Status)
+		return
+	case errors.Is(err, router.ErrNotFound):
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+		return
+	case err != nil:
+		s.logger.Error("page data error", "pattern", route.Pattern, "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal server error"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if route.Metadata.CacheControl != "" {
+		w.Header().Set("Cache-Control", route.Metadata.CacheControl)
+	}
+	w.Write(body)
+}
+
+// dataURL carries r's DataQuery over to a redirect within the app.
+func dataURL(r *http.Request, target string) string {
+	if _, ok := r.URL.Query()[DataQuery]; !ok {
+		return target
+	}
+	u, err := url.Parse(target)
+	if err != nil || u.Scheme != "" || u.Host != "" {
+		return target
+	}
+	q := u.Query()
+	q.Set(DataQuery, "")
+	u.RawQuery = q.Encode()
+	return u.String()
+}
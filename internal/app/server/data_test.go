@@ -0,0 +1,134 @@
+package server
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/brattlof/zeptor/internal/app/config"
+	"github.com/brattlof/zeptor/internal/app/render"
+	"github.com/brattlof/zeptor/internal/app/router"
+)
+
+func TestServer_Data(t *testing.T) {
+	rt, err := router.NewFS(fstest.MapFS{
+		"about/page.templ":      {Data: []byte("package about\n")},
+		"blog/slug_/page.templ": {Data: []byte("package slug_\n")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type post struct {
+		Title string `json:"title"`
+	}
+	load := func(ctx context.Context, r *http.Request) (post, error) {
+		switch slug := router.Param(r, "slug"); slug {
+		case "missing":
+			return post{}, router.NotFound()
+		case "old":
+			return post{}, router.PermanentRedirect("/blog/new")
+		default:
+			return post{Title: "post " + slug}, nil
+		}
+	}
+	rt.HandleLoad("/blog/{slug}", func(ctx context.Context, r *http.Request) (any, error) {
+		return load(ctx, r)
+	})
+	rt.HandlePage("/blog/{slug}", func(r *http.Request) (render.Component, error) {
+		return textComponent("<h1>" + router.PageProps(r, load).Title + "</h1>"), nil
+	})
+	rt.HandlePage("/about", func(r *http.Request) (render.Component, error) {
+		return textComponent("<h1>about</h1>"), nil
+	})
+
+	s := New(&config.Config{}, rt, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	s.SetupRoutes()
+
+	const browser = "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8"
+	const axios = "application/json, text/plain, */*"
+	for _, tt := range []struct {
+		target   string
+		accept   string
+		status   int
+		body     string
+		location string
+		vary     string
+	}{
+		{"/blog/hello", "application/json", http.StatusOK, `{"title":"post hello"}`, "", "Accept"},
+		{"/blog/hello", browser, http.StatusOK, "<h1>post hello</h1>", "", "Accept"},
+		{"/blog/hello", "", http.StatusOK, "<h1>post hello</h1>", "", "Accept"},
+		{"/blog/hello", axios, http.StatusOK, `{"title":"post hello"}`, "", "Accept"},
+		{"/blog/hello", "application/json, text/html", http.StatusOK, "<h1>post hello</h1>", "", "Accept"},
+		{"/blog/hello", "*/*, application/json", http.StatusOK, "<h1>post hello</h1>", "", "Accept"},
+		{"/blog/hello?_data", browser, http.StatusOK, `{"title":"post hello"}`, "", "Accept"},
+		{"/blog/missing", "application/json", http.StatusNotFound, `{"error":"not found"}`, "", "Accept"},
+		{"/blog/old?_data", "", http.StatusPermanentRedirect, "", "/blog/new?_data=", "Accept"},
+		{"/blog/old", "application/json", http.StatusPermanentRedirect, "", "/blog/new", "Accept"},
+		{"/about", "application/json", http.StatusOK, "<h1>about</h1>", "", ""},
+	} {
+		req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+		if tt.accept != "" {
+			req.Header.Set("Accept", tt.accept)
+		}
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, req)
+
+		body := strings.TrimSpace(rec.Body.String())
+		if rec.Code != tt.status || (tt.body != "" && body != tt.body) {
+			t.Errorf("GET %s (Accept %q) = %d %q, want %d %q", tt.target, tt.accept, rec.Code, body, tt.status, tt.body)
+		}
+		if got := rec.Header().Get("Location"); got != tt.location {
+			t.Errorf("GET %s Location = %q, want %q", tt.target, got, tt.location)
+		}
+		if got := rec.Header().Get("Vary"); got != tt.vary {
+			t.Errorf("GET %s Vary = %q, want %q", tt.target, got, tt.vary)
+		}
+	}
+}
+
+func TestAcceptQuality(t *testing.T) {
+	for _, tt := range []struct {
+		accept    string
+		mediaType string
+		want      float64
+	}{
+		{"application/json", "application/json", 1},
+		{"application/json", "text/html", 0},
+		{"text/html;q=0.5, */*;q=0.1", "text/html", 0.5},
+		{"text/*;q=0.3, text/html;q=0.7", "text/html", 0.7},
+		{"text/*;q=0.3", "text/html", 0.3},
+		{"*/*", "application/json", 1},
+	} {
+		if got := acceptQuality(parseAccept(tt.accept), tt.mediaType); got != tt.want {
+			t.Errorf("acceptQuality(%q, %q) = %v, want %v", tt.accept, tt.mediaType, got, tt.want)
+		}
+	}
+}
+
+func TestAcceptsHTML(t *testing.T) {
+	for _, tt := range []struct {
+		accept string
+		want   bool
+	}{
+		{"", true},
+		{"text/html", true},
+		{"text/*", true},
+		{"application/json, */*;q=0.1", true},
+		{"application/json", false},
+		{"text/html;q=0, application/json", false},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if tt.accept != "" {
+			req.Header.Set("Accept", tt.accept)
+		}
+		if got := acceptsHTML(req); got != tt.want {
+			t.Errorf("acceptsHTML(%q) = %v, want %v", tt.accept, got, tt.want)
+		}
+	}
+}
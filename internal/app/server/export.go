@@ -0,0 +1,287 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/brattlof/zeptor/internal/app/render"
+	"github.com/brattlof/zeptor/internal/app/router"
+)
+
+// ExportEnv names the directory an app pre-renders its pages into, instead
+// of serving, when zt build --ssg runs it.
+const ExportEnv = "ZEPTOR_EXPORT"
+
+// ExportStarted begins the line ExportIfRequested prints before exporting,
+// which tells zt build --ssg that the app handles ExportEnv.
+const ExportStarted = "zeptor: exporting pages to"
+
+// ExportReport describes the result of Export.
+type ExportReport struct {
+	OutDir   string
+	Pages    []ExportedPage
+	Skipped  []ExportSkip
+	Failures []ExportFailure
+	Public   int
+	Duration time.Duration
+}
+
+type ExportedPage struct {
+	Path string
+	File string
+	Size int
+}
+
+// ExportSkip is a page pattern Export did not pre-render, such as a
+// dynamic page without a StaticParams.
+type ExportSkip struct {
+	Pattern string
+	Reason  string
+}
+
+type ExportFailure struct {
+	Path string
+	Err  error
+}
+
+type exportKey struct{}
+
+type publicPathKey struct{}
+
+// ExportIfRequested exports the app into the directory named by ExportEnv,
+// when it is set, and prints the report to stdout. It reports whether it
+// exported; the error is non-nil if any page failed.
+func (s *Server) ExportIfRequested(ctx context.Context) (bool, error) {
+	outDir := os.Getenv(ExportEnv)
+	if outDir == "" {
+		return false, nil
+	}
+	fmt.Println(ExportStarted, outDir)
+
+	report, err := s.Export(ctx, outDir)
+	if err != nil {
+		return true, err
+	}
+	report.Print(os.Stdout)
+	if len(report.Failures) > 0 {
+		return true, fmt.Errorf("%d of %d pages failed", len(report.Failures), len(report.Failures)+len(report.Pages))
+	}
+	return true, nil
+}
+
+// Export renders every page that can be pre-rendered through the server's
+// handler, middleware included, into outDir as <path>/index.html, then
+// copies the public directory over. Dynamic pages are rendered once per
+// param set from their StaticParams, and each page once per locale. Pages
+// set to ssr or isr by their metadata or config are skipped.
+func (s *Server) Export(ctx context.Context, outDir string) (*ExportReport, error) {
+	start := time.Now()
+	report := &ExportReport{OutDir: outDir}
+
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return nil, fmt.Errorf("create %s: %w", outDir, err)
+	}
+
+	handler := s.Handler()
+	ctx = context.WithValue(ctx, exportKey{}, true)
+
+	for _, route := range s.router.Routes() {
+		if route.Type != router.RouteTypePage || route.Page == nil {
+			continue
+		}
+		if mode := s.modes.For(route); mode.Source != ModeSourceDefault && mode.Mode != render.ModeSSG {
+			report.Skipped = append(report.Skipped, ExportSkip{route.Pattern, fmt.Sprintf("rendered with %s (%s)", mode.Mode, mode.Source)})
+			continue
+		}
+		if route.IsDynamic && route.StaticParams == nil {
+			report.Skipped = append(report.Skipped, ExportSkip{route.Pattern, "dynamic page without StaticParams"})
+			continue
+		}
+
+		paths, err := route.StaticPaths()
+		if err != nil {
+			report.Failures = append(report.Failures, ExportFailure{route.Pattern, err})
+			continue
+		}
+
+		for _, p := range s.localePaths(paths) {
+			page, err := exportPage(ctx, handler, outDir, p)
+			if err != nil {
+				report.Failures = append(report.Failures, ExportFailure{p, err})
+				continue
+			}
+			report.Pages = append(report.Pages, page)
+		}
+	}
+
+	n, err := copyDir(s.config.Routing.PublicDir, outDir)
+	if err != nil {
+		return nil, fmt.Errorf("copy public dir: %w", err)
+	}
+	report.Public = n
+	report.Duration = time.Since(start)
+	s.staticDir.Store(&outDir)
+
+	return report, nil
+}
+
+func (s *Server) localePaths(paths []string) []string {
+	if s.i18n == nil {
+		return paths
+	}
+
+	var all []string
+	for _, p := range paths {
+		for _, locale := range s.i18n.Locales {
+			all = append(all, s.i18n.Path(locale, p))
+		}
+	}
+	return all
+}
+
+func exportPage(ctx context.Context, handler http.Handler, outDir, p string) (ExportedPage, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p, nil)
+	if err != nil {
+		return ExportedPage{}, err
+	}
+	req.RequestURI = p
+	req.Header.Set("Accept", "text/html")
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	switch {
+	case rec.Code >= 300 && rec.Code < 400:
+		return ExportedPage{}, fmt.Errorf("status %d redirecting to %s", rec.Code, rec.Header().Get("Location"))
+	case rec.Code != http.StatusOK:
+		return ExportedPage{}, fmt.Errorf("status %d", rec.Code)
+	}
+
+	unescaped, err := url.PathUnescape(p)
+	if err != nil {
+		return ExportedPage{}, err
+	}
+	file := exportFile(outDir, unescaped)
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return ExportedPage{}, err
+	}
+	if err := os.WriteFile(file, rec.Body.Bytes(), 0644); err != nil {
+		return ExportedPage{}, err
+	}
+
+	return ExportedPage{Path: p, File: file, Size: rec.Body.Len()}, nil
+}
+
+// exportFile is where the page at the decoded URL path p is written to, and
+// served from, below dir.
+func exportFile(dir, p string) string {
+	return filepath.Join(dir, filepath.FromSlash(path.Clean("/"+p)), "index.html")
+}
+
+// serveExported answers r with the page zt build --ssg pre-rendered for
+// it, if there is one, from build.staticDir or the directory this server
+// last exported into.
+func (s *Server) serveExported(w http.ResponseWriter, r *http.Request, route *router.Route) bool {
+	if r.Context().Value(exportKey{}) != nil {
+		return false
+	}
+	p, ok := r.Context().Value(publicPathKey{}).(string)
+	if !ok {
+		return false
+	}
+
+	f, err := os.Open(exportFile(*s.staticDir.Load(), p))
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil || info.IsDir() {
+		return false
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if route.Metadata.CacheControl != "" {
+		w.Header().Set("Cache-Control", route.Metadata.CacheControl)
+	}
+	http.ServeContent(w, r, "index.html", info.ModTime(), f)
+	return true
+}
+
+func copyDir(src, dst string) (int, error) {
+	if _, err := os.Stat(src); os.IsNotExist(err) {
+		return 0, nil
+	}
+
+	n := 0
+	err := filepath.WalkDir(src, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if d.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+
+		content, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		n++
+		return os.WriteFile(target, content, 0644)
+	})
+	return n, err
+}
+
+func (r *ExportReport) Print(w io.Writer) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PAGE\tSIZE\tFILE")
+	for _, p := range r.Pages {
+		file := p.File
+		if rel, err := filepath.Rel(r.OutDir, file); err == nil {
+			file = filepath.ToSlash(rel)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", p.Path, formatSize(p.Size), file)
+	}
+	tw.Flush()
+
+	for _, skip := range r.Skipped {
+		fmt.Fprintf(w, "skipped %s: %s\n", skip.Pattern, skip.Reason)
+	}
+	for _, f := range r.Failures {
+		fmt.Fprintf(w, "FAILED %s: %v\n", f.Path, f.Err)
+	}
+
+	var parts []string
+	parts = append(parts, fmt.Sprintf("%d pages", len(r.Pages)))
+	if r.Public > 0 {
+		parts = append(parts, fmt.Sprintf("%d public files", r.Public))
+	}
+	if len(r.Failures) > 0 {
+		parts = append(parts, fmt.Sprintf("%d failed", len(r.Failures)))
+	}
+	fmt.Fprintf(w, "%s into %s in %s\n", strings.Join(parts, ", "), r.OutDir, r.Duration.Round(time.Millisecond))
+}
+
+func formatSize(n int) string {
+	if n < 1024 {
+		return fmt.Sprintf("%d B", n)
+	}
+	return fmt.Sprintf("%.1f kB", float64(n)/1024)
+}
@@ -0,0 +1,158 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/brattlof/zeptor/internal/app/config"
+	"github.com/brattlof/zeptor/internal/app/render"
+	"github.com/brattlof/zeptor/internal/app/router"
+)
+
+type textComponent string
+
+func (c textComponent) Render(ctx context.Context, w io.Writer) error {
+	_, err := io.WriteString(w, string(c))
+	return err
+}
+
+func newPageServer(t *testing.T, mode string) (*Server, string) {
+	t.Helper()
+	rt, err := router.NewFS(fstest.MapFS{
+		"page.templ":              {Data: []byte("package app\n")},
+		"blog/slug_/page.templ":   {Data: []byte("package slug_\n")},
+		"docs/path___/page.templ": {Data: []byte("package path___\n")},
+		"draft/id_/page.templ":    {Data: []byte("package id_\n")},
+		"broken/page.templ":       {Data: []byte("package broken\n")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rt.HandlePage("/", func(r *http.Request) (render.Component, error) {
+		return textComponent("home"), nil
+	})
+	rt.HandlePage("/blog/{slug}", func(r *http.Request) (render.Component, error) {
+		render.CacheTag(r.Context(), "post:"+router.Param(r, "slug"))
+		return textComponent("post " + router.Param(r, "slug")), nil
+	})
+	rt.HandleMetadata("/blog", render.Metadata{Tags: []string{"blog"}})
+	rt.HandleStaticParams("/blog/{slug}", func() ([]map[string]string, error) {
+		return []map[string]string{{"slug": "hello"}, {"slug": "a b"}}, nil
+	})
+	rt.HandlePage("/docs/{...path}", func(r *http.Request) (render.Component, error) {
+		return textComponent("doc " + router.Param(r, "path")), nil
+	})
+	rt.HandleStaticParams("/docs/{...path}", func() ([]map[string]string, error) {
+		return []map[string]string{{"path": "guide/install"}}, nil
+	})
+	rt.HandlePage("/draft/{id}", func(r *http.Request) (render.Component, error) {
+		return textComponent("draft"), nil
+	})
+	rt.HandlePage("/broken", func(r *http.Request) (render.Component, error) {
+		return nil, errors.New("boom")
+	})
+
+	root := t.TempDir()
+	public := filepath.Join(root, "public")
+	if err := os.MkdirAll(filepath.Join(public, "css"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(public, "css", "site.css"), []byte("body{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	out := filepath.Join(root, "dist")
+	cfg := &config.Config{
+		Routing:   config.RoutingConfig{PublicDir: public},
+		Build:     config.BuildConfig{StaticDir: out},
+		Rendering: config.RenderingConfig{Mode: mode, ISRRevalidateS: 60},
+	}
+	s := New(cfg, rt, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	s.SetupRoutes()
+	return s, out
+}
+
+func TestServer_Export(t *testing.T) {
+	s, out := newPageServer(t, "ssr")
+
+	report, err := s.Export(context.Background(), out)
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+
+	for file, want := range map[string]string{
+		"index.html":                    "home",
+		"blog/hello/index.html":         "post hello",
+		"blog/a b/index.html":           "post a b",
+		"docs/guide/install/index.html": "doc guide/install",
+		"css/site.css":                  "body{}",
+	} {
+		got, err := os.ReadFile(filepath.Join(out, filepath.FromSlash(file)))
+		if err != nil || string(got) != want {
+			t.Errorf("%s = %q, %v, want %q", file, got, err, want)
+		}
+	}
+
+	if len(report.Pages) != 4 || report.Public != 1 {
+		t.Errorf("Pages, Public = %d, %d, want 4, 1", len(report.Pages), report.Public)
+	}
+	if len(report.Skipped) != 1 || report.Skipped[0].Pattern != "/draft/{id}" {
+		t.Errorf("Skipped = %v, want [/draft/{id}]", report.Skipped)
+	}
+	if len(report.Failures) != 1 || report.Failures[0].Path != "/broken" {
+		t.Errorf("Failures = %v, want /broken", report.Failures)
+	}
+
+	var buf strings.Builder
+	report.Print(&buf)
+	for _, want := range []string{"/blog/a%20b", "skipped /draft/{id}", "FAILED /broken: status 500", "4 pages, 1 public files, 1 failed"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Print() missing %q\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestServer_ModeSSG(t *testing.T) {
+	s, _ := newPageServer(t, "ssg")
+
+	// Pages exported elsewhere, as with zt build --out, are served from there.
+	out := t.TempDir()
+	if _, err := s.Export(context.Background(), out); err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	for file, body := range map[string]string{
+		"blog/hello/index.html": "prebuilt",
+		"blog/100%/index.html":  "percent",
+	} {
+		file = filepath.Join(out, filepath.FromSlash(file))
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(body), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for path, want := range map[string]string{
+		"/blog/hello":  "prebuilt",
+		"/blog/a%20b":  "post a b",
+		"/blog/100%25": "percent",
+		"/blog/other":  "post other",
+	} {
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusOK || rec.Body.String() != want {
+			t.Errorf("GET %s = %d %q, want 200 %q", path, rec.Code, rec.Body.String(), want)
+		}
+	}
+}
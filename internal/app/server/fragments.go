@@ -0,0 +1,97 @@
+package server
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+
+	"github.com/brattlof/zeptor/internal/app/render"
+	"github.com/brattlof/zeptor/internal/app/router"
+)
+
+// fragmentRequest reports whether r asks for part of a page, and which
+// named fragments. Boosted and history-restore htmx requests swap the whole
+// body, so they get the full page.
+func (s *Server) fragmentRequest(r *http.Request) ([]string, bool) {
+	cfg := s.config.Rendering.Fragments
+
+	if cfg.Query != "" {
+		if values, ok := r.URL.Query()[cfg.Query]; ok {
+			var names []string
+			for _, v := range values {
+				for _, name := range strings.Split(v, ",") {
+					if name = strings.TrimSpace(name); name != "" {
+						names = append(names, name)
+					}
+				}
+			}
+			return names, true
+		}
+	}
+
+	if cfg.Header == "" || r.Header.Get(cfg.Header) == "" || r.Header.Get(cfg.Header) == "false" {
+		return nil, false
+	}
+	if r.Header.Get("HX-Boosted") == "true" || r.Header.Get("HX-History-Restore-Request") == "true" {
+		return nil, false
+	}
+	return nil, true
+}
+
+// renderFragment answers r with the page without its layouts or, given
+// names, with only those templ fragments of the page and its layouts.
+// Fragments are rendered per request, whatever the page's render mode.
+func (s *Server) renderFragment(w http.ResponseWriter, r *http.Request, route *router.Route, names []string) {
+	var component render.Component
+	var err error
+	if len(names) > 0 {
+		component, err = route.Component(r)
+	} else {
+		component, err = route.Partial(r)
+	}
+	if err != nil {
+		if !isLoaderSentinel(err) {
+			s.logger.Error("page handler error", "pattern", route.Pattern, "error", err)
+		}
+		s.renderError(w, r, route, err)
+		return
+	}
+
+	var buf bytes.Buffer
+	ctx := render.WithMetadata(r.Context(), route.Metadata)
+	if len(names) > 0 {
+		err = s.renderer.RenderFragments(ctx, &buf, component, names...)
+	} else {
+		err = s.renderer.Render(ctx, &buf, component)
+	}
+	if err != nil {
+		s.logger.Error("page render error", "pattern", route.Pattern, "error", err)
+		s.renderError(w, r, route, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if route.Metadata.CacheControl != "" {
+		w.Header().Set("Cache-Control", route.Metadata.CacheControl)
+	}
+	w.Write(buf.Bytes())
+}
+
+// addVary adds each of values to h's Vary header unless it is already
+// listed.
+func addVary(h http.Header, values ...string) {
+	existing := strings.Join(h.Values("Vary"), ",")
+	for _, v := range values {
+		found := false
+		for _, e := range strings.Split(existing, ",") {
+			if strings.EqualFold(strings.TrimSpace(e), v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			h.Add("Vary", v)
+			existing += "," + v
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package server
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/a-h/templ"
+
+	"github.com/brattlof/zeptor/internal/app/config"
+	"github.com/brattlof/zeptor/internal/app/render"
+	"github.com/brattlof/zeptor/internal/app/router"
+)
+
+func TestServer_Fragments(t *testing.T) {
+	rt, err := router.NewFS(fstest.MapFS{
+		"layout.templ": {Data: []byte("package app\n")},
+		"page.templ":   {Data: []byte("package app\n")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fragment := func(id, content string) render.Component {
+		return templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+			return templ.Fragment(id).Render(templ.WithChildren(ctx, textComponent(content)), w)
+		})
+	}
+	rt.HandleLayout("/", func(r *http.Request) (render.Component, error) {
+		return templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+			children := templ.GetChildren(ctx)
+			io.WriteString(w, "<main>")
+			fragment("nav", "<nav/>").Render(ctx, w)
+			children.Render(templ.ClearChildren(ctx), w)
+			_, err := io.WriteString(w, "</main>")
+			return err
+		}), nil
+	})
+	rt.HandlePage("/", func(r *http.Request) (render.Component, error) {
+		return templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+			io.WriteString(w, "<h1>Post</h1>")
+			return fragment("comments", "<ul/>").Render(ctx, w)
+		}), nil
+	})
+
+	cfg := &config.Config{Rendering: config.RenderingConfig{
+		Mode:           "isr",
+		ISRRevalidateS: 60,
+		Fragments:      config.FragmentConfig{Header: "HX-Request", Query: "_fragment"},
+	}}
+	s := New(cfg, rt, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	s.SetupRoutes()
+
+	for _, tt := range []struct {
+		name    string
+		target  string
+		headers map[string]string
+		want    string
+	}{
+		{"full", "/", nil, "<main><nav/><h1>Post</h1><ul/></main>"},
+		{"htmx", "/", map[string]string{"HX-Request": "true"}, "<h1>Post</h1><ul/>"},
+		{"boosted", "/", map[string]string{"HX-Request": "true", "HX-Boosted": "true"}, "<main><nav/><h1>Post</h1><ul/></main>"},
+		{"query", "/?_fragment", nil, "<h1>Post</h1><ul/>"},
+		{"named", "/?_fragment=comments", nil, "<ul/>"},
+		{"named in layout", "/?_fragment=nav,comments", nil, "<nav/><ul/>"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+			s.Handler().ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusOK || rec.Body.String() != tt.want {
+				t.Errorf("GET %s = %d %q, want 200 %q", tt.target, rec.Code, rec.Body.String(), tt.want)
+			}
+			if got := rec.Header().Get("Vary"); got != "HX-Request" {
+				t.Errorf("Vary = %q, want HX-Request", got)
+			}
+		})
+	}
+
+	// The full page was cached by the first request; the htmx one after it
+	// must not have been answered from the cache.
+	if stats := s.ISR().Stats(); stats.Entries != 1 || stats.Hits != 1 {
+		t.Errorf("Stats() = %+v, want one entry hit once", stats)
+	}
+}
@@ -0,0 +1,76 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/brattlof/zeptor/internal/app/i18n"
+	"github.com/brattlof/zeptor/internal/app/render"
+	"github.com/brattlof/zeptor/internal/app/router"
+)
+
+// ISR returns the cache the server's ISR pages are served from.
+func (s *Server) ISR() *render.ISRCache {
+	return s.isr
+}
+
+// serveCached answers r from the ISR cache, revalidating the page after
+// revalidate. The page is rendered from a clone of r, since a background
+// render can outlive the request.
+func (s *Server) serveCached(w http.ResponseWriter, r *http.Request, route *router.Route, revalidate time.Duration) {
+	req := r.Clone(context.Background())
+	body, status, err := s.isr.Get(r.Context(), s.cacheKey(r), revalidate, func(ctx context.Context) ([]byte, error) {
+		render.CacheTag(ctx, route.Metadata.Tags...)
+		return s.renderBody(req.WithContext(ctx), route)
+	})
+	if err != nil {
+		s.renderError(w, r, route, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("X-Zeptor-Cache", string(status))
+	if route.Metadata.CacheControl != "" {
+		w.Header().Set("Cache-Control", route.Metadata.CacheControl)
+	} else {
+		// Shared caches may serve the page stale for as long again, as
+		// the ISR cache does while a background render replaces it.
+		seconds := int(revalidate.Seconds())
+		w.Header().Set("Cache-Control", fmt.Sprintf("public, s-maxage=%d, stale-while-revalidate=%d", seconds, seconds))
+	}
+	w.Write(body)
+}
+
+// cacheKey is the path r asked for, split from its locale, with the
+// hostname when the app serves several hosts.
+func (s *Server) cacheKey(r *http.Request) render.CacheKey {
+	key := render.CacheKey{Path: r.URL.Path}
+	if p, ok := r.Context().Value(publicPathKey{}).(string); ok {
+		key.Path = p
+	}
+	if s.i18n != nil {
+		locale, p := s.i18n.Split(key.Path)
+		if locale == "" {
+			locale = i18n.Locale(r.Context())
+		}
+		key.Locale, key.Path = locale, p
+	}
+	if len(s.router.Hosts()) > 0 {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		key.Host = strings.TrimSuffix(strings.ToLower(host), ".")
+	}
+	return key
+}
+
+// cacheable reports whether r may be answered from the ISR cache. Query
+// strings can change what a page renders, so they bypass it.
+func cacheable(r *http.Request) bool {
+	return (r.Method == http.MethodGet || r.Method == http.MethodHead) && r.URL.RawQuery == ""
+}
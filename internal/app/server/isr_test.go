@@ -0,0 +1,91 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestServer_ModeISR(t *testing.T) {
+	s, _ := newPageServer(t, "isr")
+
+	for _, tt := range []struct {
+		path  string
+		cache string
+	}{
+		{"/blog/hello", "MISS"},
+		{"/blog/hello", "HIT"},
+		{"/blog/other", "MISS"},
+		{"/blog/hello?draft=1", ""},
+	} {
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if rec.Code != http.StatusOK || rec.Header().Get("X-Zeptor-Cache") != tt.cache {
+			t.Errorf("GET %s = %d, X-Zeptor-Cache %q, want 200, %q", tt.path, rec.Code, rec.Header().Get("X-Zeptor-Cache"), tt.cache)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/blog/hello", nil))
+	if got, want := rec.Header().Get("Cache-Control"), "public, s-maxage=60, stale-while-revalidate=60"; got != want {
+		t.Errorf("Cache-Control = %q, want %q", got, want)
+	}
+
+	rec = httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/broken", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("GET /broken = %d, want 500", rec.Code)
+	}
+
+	if stats := s.ISR().Stats(); stats.Entries != 2 || stats.Hits != 2 || stats.Misses != 3 || stats.Errors != 1 {
+		t.Errorf("Stats() = %+v", stats)
+	}
+}
+
+func TestServer_Revalidate(t *testing.T) {
+	s, _ := newPageServer(t, "isr")
+	s.config.Rendering.RevalidateSecret = "s3cret"
+	s.SetupRoutes()
+
+	for _, path := range []string{"/blog/hello", "/blog/other", "/"} {
+		s.Handler().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	post := func(target, body, secret string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if secret != "" {
+			req.Header.Set("Authorization", "Bearer "+secret)
+		}
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := post(RevalidatePath, `{"paths": ["/"]}`, "wrong"); rec.Code != http.StatusUnauthorized {
+		t.Errorf("wrong secret = %d, want 401", rec.Code)
+	}
+	if rec := post(RevalidatePath, `{}`, "s3cret"); rec.Code != http.StatusBadRequest {
+		t.Errorf("empty request = %d, want 400", rec.Code)
+	}
+
+	for _, tt := range []struct {
+		target string
+		body   string
+		want   string
+	}{
+		{RevalidatePath, `{"tags": ["post:hello"]}`, `"purged":1`},
+		{RevalidatePath + "?tag=blog", ``, `"purged":1`},
+		{RevalidatePath, `{"paths": ["/", "/missing"]}`, `"purged":1`},
+	} {
+		rec := post(tt.target, tt.body, "s3cret")
+		if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), tt.want) {
+			t.Errorf("POST %s %s = %d %s, want %s", tt.target, tt.body, rec.Code, rec.Body.String(), tt.want)
+		}
+	}
+
+	if stats := s.ISR().Stats(); stats.Entries != 0 || stats.Purged != 3 {
+		t.Errorf("Stats() = %+v, want every page purged", stats)
+	}
+}
@@ -0,0 +1,31 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/brattlof/zeptor/internal/app/router"
+)
+
+// isLoaderSentinel reports whether err is a loader's router.NotFound or
+// router.Redirect rather than a failure.
+func isLoaderSentinel(err error) bool {
+	return errors.Is(err, router.ErrNotFound) || errors.As(err, new(*router.RedirectError))
+}
+
+// pageNotFound answers a page whose loader found nothing with the nearest
+// not-found.templ of its segment.
+func (s *Server) pageNotFound(w http.ResponseWriter, r *http.Request, route *router.Route) {
+	if acceptsHTML(r) {
+		rt, _, _ := s.router.ResolveHost(r.Host, r.URL.Path)
+		if boundary := rt.BoundaryFor(router.BoundaryNotFound, route.Dir); boundary != nil {
+			if s.renderBoundary(w, r, rt, boundary, http.StatusNotFound) {
+				return
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNotFound)
+	w.Write([]byte(`{"error":"not found"}`))
+}
@@ -0,0 +1,65 @@
+package server
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/brattlof/zeptor/internal/app/config"
+	"github.com/brattlof/zeptor/internal/app/render"
+	"github.com/brattlof/zeptor/internal/app/router"
+)
+
+func TestServer_Loaders(t *testing.T) {
+	rt, err := router.NewFS(fstest.MapFS{
+		"blog/slug_/page.templ": {Data: []byte("package slug_\n")},
+		"blog/not-found.templ":  {Data: []byte("package blog\n")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	load := func(ctx context.Context, r *http.Request) (string, error) {
+		switch slug := router.Param(r, "slug"); slug {
+		case "missing":
+			return "", router.NotFound()
+		case "old":
+			return "", router.PermanentRedirect("/blog/new")
+		default:
+			return "post " + slug, nil
+		}
+	}
+	rt.HandleLoad("/blog/{slug}", func(ctx context.Context, r *http.Request) (any, error) {
+		return load(ctx, r)
+	})
+	rt.HandlePage("/blog/{slug}", func(r *http.Request) (render.Component, error) {
+		return textComponent(router.PageProps(r, load)), nil
+	})
+	rt.HandleBoundary(router.BoundaryNotFound, "/blog", func(r *http.Request) (render.Component, error) {
+		return textComponent("no such post"), nil
+	})
+
+	s := New(&config.Config{}, rt, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	s.SetupRoutes()
+
+	for _, tt := range []struct {
+		path     string
+		status   int
+		body     string
+		location string
+	}{
+		{"/blog/hello", http.StatusOK, "post hello", ""},
+		{"/blog/missing", http.StatusNotFound, "no such post", ""},
+		{"/blog/old", http.StatusPermanentRedirect, "", "/blog/new"},
+	} {
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if rec.Code != tt.status || (tt.body != "" && rec.Body.String() != tt.body) || rec.Header().Get("Location") != tt.location {
+			t.Errorf("GET %s = %d %q, Location %q, want %d %q, %q", tt.path, rec.Code, rec.Body.String(), rec.Header().Get("Location"), tt.status, tt.body, tt.location)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package server
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/brattlof/zeptor/internal/app/config"
+	"github.com/brattlof/zeptor/internal/app/render"
+	"github.com/brattlof/zeptor/internal/app/router"
+)
+
+// Where a page's RouteMode came from.
+const (
+	ModeSourceConfig   = "config"
+	ModeSourceMetadata = "metadata"
+	ModeSourceDefault  = "default"
+)
+
+// RouteMode is how a page is rendered. Revalidate is only set for ISR.
+type RouteMode struct {
+	Mode       render.RenderMode
+	Revalidate time.Duration
+	Source     string
+}
+
+func (m RouteMode) String() string {
+	if m.Mode == render.ModeISR {
+		return fmt.Sprintf("%s %s", m.Mode, m.Revalidate)
+	}
+	return m.Mode.String()
+}
+
+// RenderModes picks each page's render mode: the first rendering.routes
+// entry covering its pattern, then the render and revalidate of its
+// metadata, then rendering.mode and rendering.isrRevalidateSec.
+type RenderModes struct {
+	mode       render.RenderMode
+	revalidate time.Duration
+	overrides  []*modeOverride
+}
+
+type modeOverride struct {
+	matcher    *router.PathMatcher
+	mode       string
+	revalidate time.Duration
+}
+
+// NewRenderModes compiles the overrides in cfg, skipping invalid ones and
+// reporting each. zt routes --check and New both surface the errors.
+func NewRenderModes(cfg *config.Config) (*RenderModes, []error) {
+	m := &RenderModes{
+		mode:       render.ParseRenderMode(cfg.Rendering.Mode),
+		revalidate: cfg.ISRRevalidate(),
+	}
+
+	var errs []error
+	for i, rr := range cfg.Rendering.Routes {
+		if rr.Mode != "" {
+			if _, ok := render.LookupRenderMode(rr.Mode); !ok {
+				errs = append(errs, fmt.Errorf("rendering.routes[%d]: unknown mode %q (want ssr, ssg or isr)", i, rr.Mode))
+				continue
+			}
+		}
+		matcher, err := router.NewPathMatcher(rr.Match)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("rendering.routes[%d]: %w", i, err))
+			continue
+		}
+		m.overrides = append(m.overrides, &modeOverride{
+			matcher:    matcher,
+			mode:       rr.Mode,
+			revalidate: time.Duration(rr.RevalidateSec) * time.Second,
+		})
+	}
+	return m, errs
+}
+
+// For returns the mode route is rendered with. Metadata naming an unknown
+// mode is ignored.
+func (m *RenderModes) For(route *router.Route) RouteMode {
+	rm := RouteMode{Mode: m.mode, Source: ModeSourceDefault}
+	revalidate := m.revalidate
+
+	if mode, ok := render.LookupRenderMode(route.Metadata.Render); ok {
+		rm.Mode, rm.Source = mode, ModeSourceMetadata
+	}
+	if route.Metadata.Revalidate > 0 {
+		revalidate = time.Duration(route.Metadata.Revalidate) * time.Second
+	}
+
+	for _, o := range m.overrides {
+		if !o.matcher.Covers(route.Pattern) {
+			continue
+		}
+		if o.mode != "" {
+			rm.Mode, rm.Source = render.ParseRenderMode(o.mode), ModeSourceConfig
+		}
+		if o.revalidate > 0 {
+			revalidate = o.revalidate
+		}
+		break
+	}
+
+	if rm.Mode == render.ModeISR {
+		rm.Revalidate = revalidate
+	}
+	return rm
+}
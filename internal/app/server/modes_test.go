@@ -0,0 +1,77 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/brattlof/zeptor/internal/app/config"
+	"github.com/brattlof/zeptor/internal/app/render"
+	"github.com/brattlof/zeptor/internal/app/router"
+)
+
+func TestRenderModes(t *testing.T) {
+	cfg := &config.Config{Rendering: config.RenderingConfig{
+		Mode:           "ssg",
+		ISRRevalidateS: 300,
+		Routes: []config.RenderRoute{
+			{Match: "/account/{...rest}", Mode: "ssr"},
+			{Match: "/blog/{slug}", RevalidateSec: 30},
+			{Match: "/news", Mode: "isr", RevalidateSec: 10},
+			{Match: "/users/{id:int}", Mode: "isr", RevalidateSec: 20},
+			{Match: "/bad", Mode: "csr"},
+			{Match: "no-slash", Mode: "ssr"},
+		},
+	}}
+
+	modes, errs := NewRenderModes(cfg)
+	if len(errs) != 2 {
+		t.Errorf("NewRenderModes() errors = %v, want 2", errs)
+	}
+
+	tests := []struct {
+		pattern string
+		meta    render.Metadata
+		want    RouteMode
+	}{
+		{"/", render.Metadata{}, RouteMode{render.ModeSSG, 0, ModeSourceDefault}},
+		{"/about", render.Metadata{Render: "isr"}, RouteMode{render.ModeISR, 300 * time.Second, ModeSourceMetadata}},
+		{"/blog/{slug}", render.Metadata{Render: "isr", Revalidate: 60}, RouteMode{render.ModeISR, 30 * time.Second, ModeSourceMetadata}},
+		{"/account/settings", render.Metadata{Render: "isr"}, RouteMode{render.ModeSSR, 0, ModeSourceConfig}},
+		{"/news", render.Metadata{}, RouteMode{render.ModeISR, 10 * time.Second, ModeSourceConfig}},
+		{"/typo", render.Metadata{Render: "static"}, RouteMode{render.ModeSSG, 0, ModeSourceDefault}},
+		{"/users/{id:int}", render.Metadata{}, RouteMode{render.ModeISR, 20 * time.Second, ModeSourceConfig}},
+		{"/users/{userID:int}", render.Metadata{}, RouteMode{render.ModeISR, 20 * time.Second, ModeSourceConfig}},
+		{"/users/42", render.Metadata{}, RouteMode{render.ModeISR, 20 * time.Second, ModeSourceConfig}},
+		{"/users/{id:uuid}", render.Metadata{}, RouteMode{render.ModeSSG, 0, ModeSourceDefault}},
+		{"/users/{id}", render.Metadata{}, RouteMode{render.ModeSSG, 0, ModeSourceDefault}},
+		{"/users/me", render.Metadata{}, RouteMode{render.ModeSSG, 0, ModeSourceDefault}},
+		{"/blog/{slug:[a-z]+}", render.Metadata{Render: "isr"}, RouteMode{render.ModeISR, 30 * time.Second, ModeSourceMetadata}},
+		{"/blog/{...rest}", render.Metadata{}, RouteMode{render.ModeSSG, 0, ModeSourceDefault}},
+		{"/account/{...rest}", render.Metadata{}, RouteMode{render.ModeSSR, 0, ModeSourceConfig}},
+		{"/account", render.Metadata{}, RouteMode{render.ModeSSG, 0, ModeSourceDefault}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			got := modes.For(&router.Route{Pattern: tt.pattern, Metadata: tt.meta})
+			if got != tt.want {
+				t.Errorf("For() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServer_RouteModes(t *testing.T) {
+	s, _ := newPageServer(t, "ssr")
+	s.router.HandleMetadata("/blog", render.Metadata{Render: "isr"})
+
+	for path, want := range map[string]string{"/": "", "/blog/hello": "MISS"} {
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if got := rec.Header().Get("X-Zeptor-Cache"); got != want {
+			t.Errorf("GET %s X-Zeptor-Cache = %q, want %q", path, got, want)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package server
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/brattlof/zeptor/internal/app/router"
+)
+
+// Reload rediscovers the app's routes and logs what changed. Requests in
+// flight finish on the routes they started with.
+func (s *Server) Reload() (*router.Diff, error) {
+	diff, err := s.router.Reload()
+	if err != nil {
+		s.logger.Error("route reload failed", "error", err)
+		return nil, err
+	}
+	s.logger.Info("routes reloaded", "routes", len(s.router.Routes()), "changes", diff.String())
+	return diff, nil
+}
+
+// ReloadOnSignal reloads the routes each time the process receives one of
+// sigs, SIGHUP by default, until ctx is done.
+func (s *Server) ReloadOnSignal(ctx context.Context, sigs ...os.Signal) {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{syscall.SIGHUP}
+	}
+
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, sigs...)
+	defer signal.Stop(ch)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ch:
+			s.Reload()
+		}
+	}
+}
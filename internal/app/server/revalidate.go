@@ -0,0 +1,86 @@
+package server
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/brattlof/zeptor/internal/app/render"
+)
+
+// RevalidatePath purges pages from the ISR cache on demand, e.g. from a
+// CMS webhook. It is served when rendering.revalidateSecret is set, and
+// requires it as a bearer token.
+const RevalidatePath = "/_zeptor/revalidate"
+
+// RevalidateRequest is the body of a POST to RevalidatePath. Paths and tags
+// may also be given as repeated path and tag query parameters.
+type RevalidateRequest struct {
+	Paths []string `json:"paths,omitempty"`
+	Tags  []string `json:"tags,omitempty"`
+}
+
+// RevalidateResponse answers it with the number of cached pages purged.
+type RevalidateResponse struct {
+	Paths  []string `json:"paths,omitempty"`
+	Tags   []string `json:"tags,omitempty"`
+	Purged int      `json:"purged"`
+}
+
+// revalidate purges the requested paths and tags on POST and reports the
+// cache stats on GET.
+func (s *Server) revalidate(w http.ResponseWriter, r *http.Request) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.config.Rendering.RevalidateSecret)) != 1 {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		var stats render.ISRStats
+		if s.isr != nil {
+			stats = s.isr.Stats()
+		}
+		writeJSON(w, http.StatusOK, stats)
+
+	case http.MethodPost:
+		var req RevalidateRequest
+		if r.ContentLength != 0 && strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+			if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON body"})
+				return
+			}
+		}
+		req.Paths = append(req.Paths, r.URL.Query()["path"]...)
+		req.Tags = append(req.Tags, r.URL.Query()["tag"]...)
+		if len(req.Paths) == 0 && len(req.Tags) == 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "no paths or tags to revalidate"})
+			return
+		}
+
+		resp := RevalidateResponse{Paths: req.Paths, Tags: req.Tags}
+		if s.isr != nil {
+			for _, p := range req.Paths {
+				resp.Purged += s.isr.Revalidate(p)
+			}
+			for _, tag := range req.Tags {
+				resp.Purged += s.isr.RevalidateTag(tag)
+			}
+		}
+		s.logger.Info("revalidated", "paths", req.Paths, "tags", req.Tags, "purged", resp.Purged)
+		writeJSON(w, http.StatusOK, resp)
+
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,39 @@
+package server
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/brattlof/zeptor/internal/app/config"
+	"github.com/brattlof/zeptor/internal/app/router"
+)
+
+// ManifestDirEnv names the directory zt build wrote its route manifest
+// into, build.outDir. When it is set, LoadRoutes reads the routes from
+// there instead of the app directory.
+const ManifestDirEnv = "ZEPTOR_MANIFEST_DIR"
+
+// LoadRoutes returns the app's routes and those of its configured hosts,
+// from the manifest in the directory named by ManifestDirEnv when it is
+// set, and otherwise by discovering the app directories.
+func LoadRoutes(cfg *config.Config) (*router.Router, error) {
+	if dir := os.Getenv(ManifestDirEnv); dir != "" {
+		rt, err := router.LoadManifest(os.DirFS(dir))
+		if err != nil {
+			return nil, fmt.Errorf("load %s: %w", filepath.Join(dir, router.ManifestFile), err)
+		}
+		return rt, nil
+	}
+
+	rt, err := router.New(cfg.Routing.AppDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, h := range cfg.Hosts {
+		if err := rt.AddHost(h.Host, h.AppDir, h.Prefix); err != nil {
+			return nil, err
+		}
+	}
+	return rt, nil
+}
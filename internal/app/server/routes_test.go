@@ -0,0 +1,56 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/brattlof/zeptor/internal/app/config"
+	"github.com/brattlof/zeptor/internal/app/router"
+)
+
+func TestLoadRoutes(t *testing.T) {
+	appDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(appDir, "about"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(appDir, "about", "page.templ"), []byte("package about\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{Routing: config.RoutingConfig{AppDir: appDir}}
+
+	discovered, err := LoadRoutes(cfg)
+	if err != nil {
+		t.Fatalf("LoadRoutes() error = %v", err)
+	}
+	if route, _ := discovered.Lookup("/about"); route == nil {
+		t.Fatal("Lookup(/about) = nil")
+	}
+
+	outDir := t.TempDir()
+	f, err := os.Create(filepath.Join(outDir, router.ManifestFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := discovered.WriteManifest(f); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	if err := os.RemoveAll(appDir); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv(ManifestDirEnv, outDir)
+	rt, err := LoadRoutes(cfg)
+	if err != nil {
+		t.Fatalf("LoadRoutes() from manifest error = %v", err)
+	}
+	if route, _ := rt.Lookup("/about"); route == nil {
+		t.Error("Lookup(/about) = nil with the app directory gone")
+	}
+
+	t.Setenv(ManifestDirEnv, t.TempDir())
+	if _, err := LoadRoutes(cfg); err == nil {
+		t.Error("LoadRoutes() error = nil for a directory without a manifest")
+	}
+}
@@ -0,0 +1,328 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/brattlof/zeptor/internal/app/config"
+	"github.com/brattlof/zeptor/internal/app/router"
+)
+
+// rule is a compiled redirect or rewrite. Redirects carry their status
+// code; rewrites have none.
+type rule struct {
+	source      string
+	destination string
+	status      int
+	matcher     *router.PathMatcher
+	has         []condition
+	missing     []condition
+}
+
+type condition struct {
+	typ   string
+	key   string
+	value *regexp.Regexp
+}
+
+var destinationParam = regexp.MustCompile(`\{(\.\.\.)?([A-Za-z]\w*)\??\}`)
+
+// ValidateRules reports every invalid redirect and rewrite in cfg.
+func ValidateRules(cfg *config.Config) []error {
+	_, _, errs := compileRules(cfg)
+	return errs
+}
+
+func compileRules(cfg *config.Config) ([]*rule, []*rule, []error) {
+	var redirects, rewrites []*rule
+	var errs []error
+
+	for i, rd := range cfg.Redirects {
+		status := http.StatusTemporaryRedirect
+		if rd.Permanent {
+			status = http.StatusPermanentRedirect
+		}
+		rl, err := compileRule(rd.Source, rd.Destination, status, rd.Has, rd.Missing)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("redirects[%d]: %w", i, err))
+			continue
+		}
+		redirects = append(redirects, rl)
+	}
+
+	for i, rw := range cfg.Rewrites {
+		if !strings.HasPrefix(rw.Destination, "/") {
+			errs = append(errs, fmt.Errorf("rewrites[%d]: destination %q must be a path", i, rw.Destination))
+			continue
+		}
+		rl, err := compileRule(rw.Source, rw.Destination, 0, rw.Has, rw.Missing)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("rewrites[%d]: %w", i, err))
+			continue
+		}
+		rewrites = append(rewrites, rl)
+	}
+
+	return redirects, rewrites, errs
+}
+
+func compileRule(source, destination string, status int, has, missing []config.RuleCondition) (*rule, error) {
+	if destination == "" {
+		return nil, fmt.Errorf("%s: missing destination", source)
+	}
+
+	matcher, err := router.NewPathMatcher(source)
+	if err != nil {
+		return nil, err
+	}
+
+	rl := &rule{
+		source:      source,
+		destination: destination,
+		status:      status,
+		matcher:     matcher,
+	}
+	if rl.has, err = compileConditions(has); err != nil {
+		return nil, fmt.Errorf("%s: %w", source, err)
+	}
+	if rl.missing, err = compileConditions(missing); err != nil {
+		return nil, fmt.Errorf("%s: %w", source, err)
+	}
+	return rl, nil
+}
+
+func compileConditions(conds []config.RuleCondition) ([]condition, error) {
+	compiled := make([]condition, 0, len(conds))
+	for _, c := range conds {
+		switch c.Type {
+		case "header", "query", "cookie":
+		default:
+			return nil, fmt.Errorf("condition type %q must be header, query or cookie", c.Type)
+		}
+		if c.Key == "" {
+			return nil, fmt.Errorf("%s condition needs a key", c.Type)
+		}
+
+		cond := condition{typ: c.Type, key: c.Key}
+		if c.Value != "" {
+			re, err := regexp.Compile(`^(?:` + c.Value + `)$`)
+			if err != nil {
+				return nil, fmt.Errorf("%s condition %s: %w", c.Type, c.Key, err)
+			}
+			cond.value = re
+		}
+		compiled = append(compiled, cond)
+	}
+	return compiled, nil
+}
+
+func (c condition) match(r *http.Request) bool {
+	var value string
+	var ok bool
+
+	switch c.typ {
+	case "header":
+		var values []string
+		values, ok = r.Header[http.CanonicalHeaderKey(c.key)]
+		if ok {
+			value = values[0]
+		}
+	case "query":
+		ok = r.URL.Query().Has(c.key)
+		value = r.URL.Query().Get(c.key)
+	case "cookie":
+		if cookie, err := r.Cookie(c.key); err == nil {
+			value, ok = cookie.Value, true
+		}
+	}
+
+	return ok && (c.value == nil || c.value.MatchString(value))
+}
+
+func (rl *rule) match(r *http.Request, path string) (map[string]string, bool) {
+	_, params, ok := rl.matcher.Match(path)
+	if !ok {
+		return nil, false
+	}
+	for _, c := range rl.has {
+		if !c.match(r) {
+			return nil, false
+		}
+	}
+	for _, c := range rl.missing {
+		if c.match(r) {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+// expand substitutes the matched params into the destination, escaping
+// each path segment.
+func (rl *rule) expand(params map[string]string) string {
+	return destinationParam.ReplaceAllStringFunc(rl.destination, func(token string) string {
+		m := destinationParam.FindStringSubmatch(token)
+		value := params[m[2]]
+		if m[1] == "" {
+			return url.PathEscape(value)
+		}
+		if value == "" {
+			return ""
+		}
+		return strings.TrimPrefix(router.EscapeSegments(strings.Split(value, "/")), "/")
+	})
+}
+
+// routingRules applies the case and trailing-slash policies, then the
+// configured redirects and rewrites, before the request is routed.
+func (s *Server) routingRules(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p := r.URL.Path
+
+		switch s.config.Routing.Case {
+		case config.CaseLower:
+			if lower := strings.ToLower(p); lower != p {
+				redirectTo(w, r, escapePath(lower), http.StatusPermanentRedirect)
+				return
+			}
+		case config.CaseInsensitive:
+			p = s.foldCase(r, p)
+		}
+
+		if p != "/" {
+			switch s.config.Routing.TrailingSlash {
+			case config.TrailingSlashNever:
+				if strings.HasSuffix(p, "/") {
+					redirectTo(w, r, escapePath(strings.TrimRight(p, "/")), http.StatusPermanentRedirect)
+					return
+				}
+			case config.TrailingSlashAlways:
+				if !strings.HasSuffix(p, "/") && !strings.Contains(path.Base(p), ".") {
+					redirectTo(w, r, escapePath(p+"/"), http.StatusPermanentRedirect)
+					return
+				}
+			}
+		}
+
+		for _, rl := range s.redirects {
+			if params, ok := rl.match(r, p); ok {
+				redirectTo(w, r, rl.expand(params), rl.status)
+				return
+			}
+		}
+
+		for _, rl := range s.rewrites {
+			if params, ok := rl.match(r, p); ok {
+				r = rewrite(r, rl.expand(params))
+				next.ServeHTTP(w, r)
+				return
+			}
+		}
+
+		if p != r.URL.Path {
+			r = rewrite(r, p)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// foldCase lowercases p to find its route, then restores the case of the
+// segments the route captures as params, so handlers get them as sent.
+func (s *Server) foldCase(r *http.Request, p string) string {
+	lower := strings.ToLower(p)
+	route, offset := s.routeFor(r, lower)
+	if route == nil || !route.IsDynamic {
+		return lower
+	}
+
+	original, segs := strings.Split(p, "/"), strings.Split(lower, "/")
+	for j, part := range strings.Split(strings.Trim(route.Pattern, "/"), "/") {
+		i := j + offset
+		if i < 1 || i >= len(segs) || !strings.HasPrefix(part, "{") {
+			continue
+		}
+		if strings.HasPrefix(part, "{...") {
+			copy(segs[i:], original[i:])
+			break
+		}
+		segs[i] = original[i]
+	}
+
+	// Constraints are case-sensitive, so a value may only match lowercased.
+	folded := strings.Join(segs, "/")
+	if match, _ := s.routeFor(r, folded); match != route {
+		return lower
+	}
+	return folded
+}
+
+// routeFor finds the route serving the public path p, as serveRoute does,
+// and the index of p's segment that the route pattern's first one is at
+// when split on "/". Host prefixes can make that index less than 1.
+func (s *Server) routeFor(r *http.Request, p string) (*router.Route, int) {
+	path, offset := p, 1
+	if s.i18n != nil {
+		if locale, rest := s.i18n.Split(p); locale != "" {
+			path, offset = rest, 2
+		}
+	}
+	depth := segmentCount(path)
+	rt, path, _ := s.router.ResolveHost(r.Host, path)
+	offset -= segmentCount(path) - depth
+
+	ps := router.AcquireParams()
+	defer router.ReleaseParams(ps)
+	return rt.Find(path, ps), offset
+}
+
+func segmentCount(p string) int {
+	p = strings.Trim(p, "/")
+	if p == "" {
+		return 0
+	}
+	return strings.Count(p, "/") + 1
+}
+
+// redirectTo keeps the request's query unless target sets its own. Leading
+// slashes of a local target are collapsed, since a Location of //host is
+// another site.
+func redirectTo(w http.ResponseWriter, r *http.Request, target string, status int) {
+	if target == "" || strings.HasPrefix(target, "/") {
+		target = "/" + strings.TrimLeft(target, "/")
+	}
+	if r.URL.RawQuery != "" && !strings.Contains(target, "?") {
+		target += "?" + r.URL.RawQuery
+	}
+	http.Redirect(w, r, target, status)
+}
+
+func escapePath(p string) string {
+	return (&url.URL{Path: p}).EscapedPath()
+}
+
+func rewrite(r *http.Request, target string) *http.Request {
+	u, err := url.Parse(target)
+	if err != nil {
+		return r
+	}
+
+	r2 := new(http.Request)
+	*r2 = *r
+	r2.URL = new(url.URL)
+	*r2.URL = *r.URL
+	r2.URL.Path = u.Path
+	r2.URL.RawPath = u.RawPath
+
+	if u.RawQuery != "" {
+		query := r.URL.Query()
+		for k, v := range u.Query() {
+			query[k] = v
+		}
+		r2.URL.RawQuery = query.Encode()
+	}
+	return r2
+}
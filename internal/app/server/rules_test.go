@@ -0,0 +1,170 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/brattlof/zeptor/internal/app/config"
+)
+
+func TestRoutingRules(t *testing.T) {
+	cfg := &config.Config{
+		Routing: config.RoutingConfig{
+			TrailingSlash: config.TrailingSlashNever,
+			Case:          config.CaseLower,
+		},
+		Redirects: []config.RedirectRule{
+			{Source: "/old-blog/{slug}", Destination: "/blog/{slug}", Permanent: true},
+			{Source: "/docs/{...path}", Destination: "https://docs.example.com/{...path}"},
+			{
+				Source:      "/beta",
+				Destination: "/",
+				Missing:     []config.RuleCondition{{Type: "cookie", Key: "beta"}},
+			},
+		},
+		Rewrites: []config.RewriteRule{
+			{Source: "/u/{id:int}", Destination: "/users/{id}?tab=profile"},
+			{
+				Source:      "/search",
+				Destination: "/search/mobile",
+				Has:         []config.RuleCondition{{Type: "header", Key: "User-Agent", Value: ".*Mobile.*"}},
+			},
+		},
+	}
+
+	redirects, rewrites, errs := compileRules(cfg)
+	if len(errs) > 0 {
+		t.Fatalf("compileRules() errors = %v", errs)
+	}
+	s := &Server{config: cfg, redirects: redirects, rewrites: rewrites}
+
+	handler := s.routingRules(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.RequestURI()))
+	}))
+
+	tests := []struct {
+		name     string
+		path     string
+		header   string
+		cookie   string
+		status   int
+		location string
+		body     string
+	}{
+		{"trailing slash", "/about/", "", "", http.StatusPermanentRedirect, "/about", ""},
+		{"uppercase", "/About?x=1", "", "", http.StatusPermanentRedirect, "/about?x=1", ""},
+		{"escaped slash redirect", "/a%20b/", "", "", http.StatusPermanentRedirect, "/a%20b", ""},
+		{"permanent redirect", "/old-blog/hello%20world", "", "", http.StatusPermanentRedirect, "/blog/hello%20world", ""},
+		{"external catch-all", "/docs/a/b", "", "", http.StatusTemporaryRedirect, "https://docs.example.com/a/b", ""},
+		{"missing cookie", "/beta", "", "", http.StatusTemporaryRedirect, "/", ""},
+		{"cookie present", "/beta", "", "beta=1", http.StatusOK, "", "/beta"},
+		{"rewrite", "/u/42", "", "", http.StatusOK, "", "/users/42?tab=profile"},
+		{"constraint miss", "/u/bob", "", "", http.StatusOK, "", "/u/bob"},
+		{"header rewrite", "/search", "iPhone Mobile Safari", "", http.StatusOK, "", "/search/mobile"},
+		{"header miss", "/search", "curl", "", http.StatusOK, "", "/search"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set("User-Agent", tt.header)
+			}
+			if tt.cookie != "" {
+				req.Header.Set("Cookie", tt.cookie)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if got := rec.Header().Get("Location"); got != tt.location {
+				t.Errorf("Location = %q, want %q", got, tt.location)
+			}
+			if tt.body != "" && rec.Body.String() != tt.body {
+				t.Errorf("body = %q, want %q", rec.Body.String(), tt.body)
+			}
+		})
+	}
+}
+
+func TestCompileRules_Invalid(t *testing.T) {
+	cfg := &config.Config{
+		Redirects: []config.RedirectRule{
+			{Source: "blog", Destination: "/"},
+			{Source: "/a/{id:[}", Destination: "/"},
+		},
+		Rewrites: []config.RewriteRule{
+			{Source: "/a", Destination: "https://example.com"},
+			{Source: "/b", Destination: "/c", Has: []config.RuleCondition{{Type: "ip", Key: "x"}}},
+		},
+	}
+
+	redirects, rewrites, errs := compileRules(cfg)
+	if len(errs) != 4 {
+		t.Errorf("compileRules() errors = %v, want 4", errs)
+	}
+	if len(redirects) != 0 || len(rewrites) != 0 {
+		t.Errorf("compiled %d redirects and %d rewrites, want none", len(redirects), len(rewrites))
+	}
+}
+
+func TestRoutingRules_NoOpenRedirect(t *testing.T) {
+	tests := []struct {
+		name     string
+		routing  config.RoutingConfig
+		path     string
+		location string
+	}{
+		{"trailing slash never", config.RoutingConfig{TrailingSlash: config.TrailingSlashNever}, "//evil.com/", "/evil.com"},
+		{"case lower", config.RoutingConfig{Case: config.CaseLower}, "//Evil.com", "/evil.com"},
+		{"always and lower", config.RoutingConfig{TrailingSlash: config.TrailingSlashAlways, Case: config.CaseLower}, "//Evil.com", "/evil.com"},
+		{"always", config.RoutingConfig{TrailingSlash: config.TrailingSlashAlways}, "//evil", "/evil/"},
+		{"catch-all redirect", config.RoutingConfig{}, "/go//evil.com", "/evil.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{
+				Routing:   tt.routing,
+				Redirects: []config.RedirectRule{{Source: "/go/{...rest}", Destination: "/{...rest}"}},
+			}
+			redirects, _, errs := compileRules(cfg)
+			if len(errs) > 0 {
+				t.Fatalf("compileRules() errors = %v", errs)
+			}
+			s := &Server{config: cfg, redirects: redirects}
+			handler := s.routingRules(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.URL.Path = tt.path
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if got := rec.Header().Get("Location"); got != tt.location {
+				t.Errorf("Location = %q, want %q", got, tt.location)
+			}
+		})
+	}
+}
+
+func TestRoutingRules_CaseInsensitive(t *testing.T) {
+	s, _ := newPageServer(t, "ssr")
+	s.config.Routing.Case = config.CaseInsensitive
+	handler := s.routingRules(s.Handler())
+
+	for path, want := range map[string]string{
+		"/BLOG/Hello":         "post Hello",
+		"/Blog/a%20B/":        "post a B",
+		"/DOCS/Guide/Install": "doc Guide/Install",
+		"/":                   "home",
+	} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusOK || rec.Body.String() != want {
+			t.Errorf("GET %s = %d %q, want 200 %q", path, rec.Code, rec.Body.String(), want)
+		}
+	}
+}
@@ -1,14 +1,24 @@
 package server
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"runtime/debug"
+	"slices"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 
 	"github.com/brattlof/zeptor/internal/app/config"
+	"github.com/brattlof/zeptor/internal/app/i18n"
+	"github.com/brattlof/zeptor/internal/app/render"
 	"github.com/brattlof/zeptor/internal/app/router"
 	"github.com/brattlof/zeptor/pkg/plugin"
 )
@@ -16,22 +26,67 @@ import (
 type Server struct {
 	config   *config.Config
 	router   *router.Router
+	renderer *render.Renderer
 	mux      *chi.Mux
 	registry *plugin.Registry
 	logger   *slog.Logger
+
+	redirects []*rule
+	rewrites  []*rule
+	i18n      *i18n.Bundle
+	modes     *RenderModes
+	isr       *render.ISRCache
+	// staticDir is where SSG pages are served from.
+	staticDir atomic.Pointer[string]
 }
 
 func New(cfg *config.Config, rt *router.Router, registry *plugin.Registry, logger *slog.Logger) *Server {
 	if logger == nil {
 		logger = slog.Default()
 	}
-	return &Server{
-		config:   cfg,
-		router:   rt,
-		mux:      chi.NewRouter(),
-		registry: registry,
-		logger:   logger,
+
+	redirects, rewrites, errs := compileRules(cfg)
+	for _, err := range errs {
+		logger.Error("invalid routing rule", "error", err)
+	}
+
+	modes, errs := NewRenderModes(cfg)
+	for _, err := range errs {
+		logger.Error("invalid render mode override", "error", err)
+	}
+
+	var bundle *i18n.Bundle
+	if cfg.I18n.Enabled() {
+		var err error
+		if bundle, err = i18n.New(cfg.I18n, rt.FS()); err != nil {
+			logger.Error("invalid i18n config", "error", err)
+		} else {
+			for _, route := range bundle.Shadowed(rt) {
+				logger.Warn("route is shadowed by a locale prefix", "pattern", route.Pattern)
+			}
+		}
+	}
+
+	s := &Server{
+		config:    cfg,
+		router:    rt,
+		renderer:  render.NewRenderer(render.ParseRenderMode(cfg.Rendering.Mode)),
+		mux:       chi.NewRouter(),
+		registry:  registry,
+		logger:    logger,
+		redirects: redirects,
+		rewrites:  rewrites,
+		i18n:      bundle,
+		modes:     modes,
+		isr:       render.NewISRCache(cfg.ISRRevalidate(), cfg.Rendering.ISRMaxEntries),
+	}
+	s.isr.OnError = func(key render.CacheKey, err error) {
+		logger.Error("page regeneration failed", "host", key.Host, "locale", key.Locale, "path", key.Path, "error", err)
 	}
+	render.SetDefaultCache(s.isr)
+	staticDir := cfg.Build.StaticDir
+	s.staticDir.Store(&staticDir)
+	return s
 }
 
 func (s *Server) SetupMiddlewares() {
@@ -40,6 +95,7 @@ func (s *Server) SetupMiddlewares() {
 	s.mux.Use(middleware.Logger)
 	s.mux.Use(middleware.Recoverer)
 	s.mux.Use(middleware.Timeout(60 * time.Second))
+	s.mux.Use(s.routingRules)
 
 	if s.config.EBPF.Enabled {
 		s.mux.Use(s.eBPFMiddleware)
@@ -120,36 +176,198 @@ func (s *Server) eBPFMiddleware(next http.Handler) http.Handler {
 }
 
 func (s *Server) SetupRoutes() {
-	for pattern, route := range s.router.StaticRoutes() {
-		s.mux.Get(pattern, s.wrapHandler(route))
+	s.mux.Get("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status":"ok"}`))
+	})
+
+	if s.config.Rendering.RevalidateSecret != "" {
+		s.mux.Handle(RevalidatePath, http.HandlerFunc(s.revalidate))
 	}
 
-	for _, route := range s.router.DynamicRoutes() {
-		s.mux.Get(route.Pattern, s.wrapHandler(route))
+	s.mux.Handle("/*", http.HandlerFunc(s.serveRoute))
+	s.mux.NotFound(s.notFound)
+}
+
+func (s *Server) serveRoute(w http.ResponseWriter, r *http.Request) {
+	r = r.WithContext(context.WithValue(r.Context(), publicPathKey{}, r.URL.Path))
+	path, locale, prefixed := s.splitLocale(r)
+	rt, path, hostParams := s.router.ResolveHost(r.Host, path)
+	ps := router.AcquireParams()
+	defer router.ReleaseParams(ps)
+	for k, v := range hostParams {
+		*ps = append(*ps, router.PathParam{Key: k, Value: v})
+	}
+	route := rt.Find(path, ps)
+	if route == nil {
+		s.notFound(w, r)
+		return
 	}
 
-	s.mux.Get("/health", func(w http.ResponseWriter, r *http.Request) {
+	if s.i18n != nil {
+		if !prefixed && route.Type == router.RouteTypePage && (r.Method == http.MethodGet || r.Method == http.MethodHead) &&
+			(locale != s.i18n.Default || s.i18n.PrefixDefault) {
+			w.Header().Add("Vary", "Accept-Language, Cookie")
+			redirectTo(w, r, escapePath(s.i18n.Path(locale, r.URL.Path)), http.StatusTemporaryRedirect)
+			return
+		}
+		r = r.WithContext(i18n.WithLocale(r.Context(), s.i18n, locale))
+		w.Header().Set("Content-Language", locale)
+	}
+
+	if path != r.URL.Path {
+		// Middleware matchers see the path within the app, as with rewrites.
+		r = rewrite(r, escapePath(path))
+	}
+
+	// The request can outlive ps, in a background ISR render or a deferred
+	// section, so it keeps a copy.
+	r = router.WithParamList(r, route, slices.Clone(*ps))
+
+	rw := &responseWriter{ResponseWriter: w, status: http.StatusOK}
+	defer func() {
+		if v := recover(); v != nil {
+			if v == http.ErrAbortHandler {
+				panic(v)
+			}
+			s.logger.Error("panic serving route", "pattern", route.Pattern, "panic", v, "stack", string(debug.Stack()))
+			if rw.wrote {
+				return
+			}
+			s.renderError(w, r, route, fmt.Errorf("panic: %v", v))
+		}
+	}()
+
+	route.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.dispatch(w, r, route)
+	})).ServeHTTP(rw, r)
+}
+
+func (s *Server) dispatch(w http.ResponseWriter, r *http.Request, route *router.Route) {
+	if !route.Allows(r.Method) {
+		w.Header().Set("Allow", strings.Join(route.Allow(), ", "))
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
 		w.Header().Set("Content-Type", "application/json")
-		w.Write([]byte(`{"status":"ok"}`))
-	})
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		w.Write([]byte(`{"error":"method not allowed"}`))
+		return
+	}
+
+	if handler := route.MethodHandler(r.Method); handler != nil {
+		handler(w, r)
+		return
+	}
+
+	if route.Page != nil {
+		s.renderPage(w, r, route)
+		return
+	}
+
+	s.placeholder(w, route)
+}
+
+// splitLocale returns r's path without its locale prefix and the locale,
+// detected from the request when the path has none.
+func (s *Server) splitLocale(r *http.Request) (path, locale string, prefixed bool) {
+	if s.i18n == nil {
+		return r.URL.Path, "", false
+	}
+	locale, path = s.i18n.Split(r.URL.Path)
+	if locale != "" {
+		return path, locale, true
+	}
+	return path, s.i18n.DetectLocale(r), false
+}
+
+func (s *Server) notFound(w http.ResponseWriter, r *http.Request) {
+	if acceptsHTML(r) {
+		path, locale, _ := s.splitLocale(r)
+		if s.i18n != nil {
+			r = r.WithContext(i18n.WithLocale(r.Context(), s.i18n, locale))
+		}
+		rt, path, hostParams := s.router.ResolveHost(r.Host, path)
+		if boundary, params := rt.NotFoundFor(path); boundary != nil {
+			for k, v := range hostParams {
+				params[k] = v
+			}
+			r = router.WithParams(r, nil, params)
+			if s.renderBoundary(w, r, rt, boundary, http.StatusNotFound) {
+				return
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNotFound)
+	w.Write([]byte(`{"error":"not found"}`))
+}
+
+// renderError answers a failed page or handler with the nearest error.templ,
+// falling back to a plain 500 for API routes and apps without one. Loaders
+// that returned router.NotFound or router.Redirect get a 404 or a redirect.
+func (s *Server) renderError(w http.ResponseWriter, r *http.Request, route *router.Route, err error) {
+	var redirect *router.RedirectError
+	if errors.As(err, &redirect) {
+		http.Redirect(w, r, redirect.URL, redirect.Status)
+		return
+	}
+	if errors.Is(err, router.ErrNotFound) {
+		s.pageNotFound(w, r, route)
+		return
+	}
 
-	s.mux.NotFound(func(w http.ResponseWriter, r *http.Request) {
+	if route.Type == router.RouteTypePage && acceptsHTML(r) {
+		rt, _, _ := s.router.ResolveHost(r.Host, r.URL.Path)
+		if boundary := rt.BoundaryFor(router.BoundaryError, route.Dir); boundary != nil {
+			if s.renderBoundary(w, router.WithError(r, err), rt, boundary, http.StatusInternalServerError) {
+				return
+			}
+		}
+	}
+
+	if route.Type == router.RouteTypeAPI {
 		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusNotFound)
-		w.Write([]byte(`{"error":"not found"}`))
-	})
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"error":"internal server error"}`))
+		return
+	}
+	http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 }
 
-func (s *Server) wrapHandler(route *router.Route) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		if route.Handler != nil {
-			route.Handler(w, r)
-			return
+func (s *Server) renderBoundary(w http.ResponseWriter, r *http.Request, rt *router.Router, boundary *router.Boundary, status int) bool {
+	var buf bytes.Buffer
+	err := func() (err error) {
+		defer func() {
+			if v := recover(); v != nil {
+				err = fmt.Errorf("panic: %v", v)
+			}
+		}()
+
+		component, err := rt.BoundaryComponent(r, boundary)
+		if err != nil {
+			return err
 		}
+		ctx := render.WithMetadata(r.Context(), rt.MetadataFor(boundary.Dir))
+		return s.renderer.Render(ctx, &buf, component)
+	}()
+	if err != nil {
+		s.logger.Error("boundary render error", "file", boundary.File, "error", err)
+		return false
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	w.Write(buf.Bytes())
+	return true
+}
 
-		w.Header().Set("Content-Type", "text/html")
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte(`<!DOCTYPE html>
+func (s *Server) placeholder(w http.ResponseWriter, route *router.Route) {
+	w.Header().Set("Content-Type", "text/html")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(`<!DOCTYPE html>
 <html>
 <head><title>Zeptor</title></head>
 <body>
@@ -158,7 +376,77 @@ func (s *Server) wrapHandler(route *router.Route) http.HandlerFunc {
 <p><em>Handler not yet implemented</em></p>
 </body>
 </html>`))
+}
+
+// renderPage buffers the page so that an error or panic part way through
+// can still be answered with the error page, and streams its deferred
+// sections after it. Fragment requests get part of the page instead, and
+// JSON requests to pages with a loader get its props. SSG pages are served
+// from their pre-rendered file and ISR pages from the cache, when
+// possible; these and exported pages wait for their deferred sections
+// instead.
+func (s *Server) renderPage(w http.ResponseWriter, r *http.Request, route *router.Route) {
+	if h := s.config.Rendering.Fragments.Header; h != "" {
+		addVary(w.Header(), h)
+	}
+	if route.Loader != nil {
+		addVary(w.Header(), "Accept")
+		if wantsData(r) {
+			s.serveData(w, r, route)
+			return
+		}
+	}
+	if names, ok := s.fragmentRequest(r); ok {
+		s.renderFragment(w, r, route, names)
+		return
+	}
+
+	switch mode := s.modes.For(route); mode.Mode {
+	case render.ModeSSG:
+		if s.serveExported(w, r, route) {
+			return
+		}
+	case render.ModeISR:
+		if cacheable(r) {
+			s.serveCached(w, r, route, mode.Revalidate)
+			return
+		}
+	}
+
+	if r.Context().Value(exportKey{}) == nil {
+		s.streamPage(w, r, route)
+		return
+	}
+
+	body, err := s.renderBody(r, route)
+	if err != nil {
+		s.renderError(w, r, route, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if route.Metadata.CacheControl != "" {
+		w.Header().Set("Cache-Control", route.Metadata.CacheControl)
+	}
+	w.Write(body)
+}
+
+func (s *Server) renderBody(r *http.Request, route *router.Route) ([]byte, error) {
+	component, err := route.Component(r)
+	if err != nil {
+		if !isLoaderSentinel(err) {
+			s.logger.Error("page handler error", "pattern", route.Pattern, "error", err)
+		}
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	ctx := render.WithMetadata(r.Context(), route.Metadata)
+	if err := s.renderer.Render(ctx, &buf, component); err != nil {
+		s.logger.Error("page render error", "pattern", route.Pattern, "error", err)
+		return nil, err
 	}
+	return buf.Bytes(), nil
 }
 
 func (s *Server) Handler() http.Handler {
@@ -192,13 +480,31 @@ func (s *Server) Delete(pattern string, handler http.HandlerFunc) {
 type responseWriter struct {
 	http.ResponseWriter
 	status int
+	wrote  bool
 }
 
 func (rw *responseWriter) WriteHeader(code int) {
 	rw.status = code
+	rw.wrote = true
 	rw.ResponseWriter.WriteHeader(code)
 }
 
+func (rw *responseWriter) Write(b []byte) (int, error) {
+	rw.wrote = true
+	return rw.ResponseWriter.Write(b)
+}
+
+func (rw *responseWriter) Flush() {
+	rw.wrote = true
+	if f, ok := rw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
 type routerAdapter struct {
 	mux *chi.Mux
 }
@@ -0,0 +1,61 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/brattlof/zeptor/internal/app/render"
+	"github.com/brattlof/zeptor/internal/app/router"
+)
+
+// streamPage renders the page's shell, sends it as soon as it is done and
+// follows it with the sections it deferred with render.Defer. Those without
+// a fallback of their own show the nearest loading.templ until they arrive;
+// those that fail are replaced with the nearest error.templ. The loaders
+// run first and block the whole shell, head included, since their errors
+// still decide the status.
+func (s *Server) streamPage(w http.ResponseWriter, r *http.Request, route *router.Route) {
+	rt, _, _ := s.router.ResolveHost(r.Host, r.URL.Path)
+
+	stream := render.NewStream()
+	if boundary := rt.BoundaryFor(router.BoundaryLoading, route.Dir); boundary != nil {
+		if fallback, err := boundary.Component(r); err == nil {
+			stream.Fallback = fallback
+		} else {
+			s.logger.Error("loading boundary error", "file", boundary.File, "error", err)
+		}
+	}
+	stream.OnError = func(err error) render.Component {
+		s.logger.Error("deferred render error", "pattern", route.Pattern, "error", err)
+		boundary := rt.BoundaryFor(router.BoundaryError, route.Dir)
+		if boundary == nil {
+			return nil
+		}
+		component, err := boundary.Component(router.WithError(r, err))
+		if err != nil {
+			return nil
+		}
+		return component
+	}
+
+	ctx := render.WithStream(render.WithMetadata(r.Context(), route.Metadata), stream)
+	body, err := s.renderBody(r.WithContext(ctx), route)
+	if err != nil {
+		s.renderError(w, r, route, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if route.Metadata.CacheControl != "" {
+		w.Header().Set("Cache-Control", route.Metadata.CacheControl)
+	}
+	if stream.Len() == 0 {
+		w.Write(body)
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	flush := func() { rc.Flush() }
+	if err := stream.Send(r.Context(), w, body, flush); err != nil {
+		s.logger.Warn("stream aborted", "pattern", route.Pattern, "error", err)
+	}
+}
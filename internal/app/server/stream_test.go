@@ -0,0 +1,98 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/a-h/templ"
+
+	"github.com/brattlof/zeptor/internal/app/config"
+	"github.com/brattlof/zeptor/internal/app/render"
+	"github.com/brattlof/zeptor/internal/app/router"
+)
+
+func newStreamServer(t *testing.T, mode string) *Server {
+	t.Helper()
+	rt, err := router.NewFS(fstest.MapFS{
+		"page.templ":    {Data: []byte("package app\n")},
+		"loading.templ": {Data: []byte("package app\n")},
+		"error.templ":   {Data: []byte("package app\n")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	failing := templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+		return errors.New("query failed")
+	})
+	rt.HandlePage("/", func(r *http.Request) (render.Component, error) {
+		return templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+			io.WriteString(w, "<html><body><h1>Stats</h1>")
+			if err := render.Defer(textComponent("visits: 42"), nil).Render(ctx, w); err != nil {
+				return err
+			}
+			if err := render.Defer(failing, textComponent("counting")).Render(ctx, w); err != nil {
+				return err
+			}
+			_, err := io.WriteString(w, "</body></html>")
+			return err
+		}), nil
+	})
+	rt.HandleBoundary(router.BoundaryLoading, "/", func(r *http.Request) (render.Component, error) {
+		return textComponent("loading"), nil
+	})
+	rt.HandleBoundary(router.BoundaryError, "/", func(r *http.Request) (render.Component, error) {
+		return textComponent("error: " + router.RouteError(r).Error()), nil
+	})
+
+	cfg := &config.Config{Rendering: config.RenderingConfig{Mode: mode, ISRRevalidateS: 60}}
+	s := New(cfg, rt, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	s.SetupRoutes()
+	return s
+}
+
+func TestServer_Stream(t *testing.T) {
+	s := newStreamServer(t, "ssr")
+
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	body := rec.Body.String()
+
+	if rec.Code != http.StatusOK || !rec.Flushed {
+		t.Fatalf("GET / = %d, flushed %v, want 200, true", rec.Code, rec.Flushed)
+	}
+	shell := `<html><body><h1>Stats</h1>` +
+		`<zeptor-deferred id="zd-0" style="display:contents">loading</zeptor-deferred>` +
+		`<zeptor-deferred id="zd-1" style="display:contents">counting</zeptor-deferred>`
+	if !strings.HasPrefix(body, shell) || !strings.HasSuffix(body, "</body></html>") {
+		t.Errorf("GET / = %q, want the shell first and its closing tags last", body)
+	}
+	for _, chunk := range []string{
+		`<template id="zd-c0">visits: 42</template>`,
+		`<template id="zd-c1">error: query failed</template>`,
+	} {
+		if !strings.Contains(body, chunk) {
+			t.Errorf("GET / missing chunk %q in %q", chunk, body)
+		}
+	}
+}
+
+func TestServer_StreamBuffered(t *testing.T) {
+	s := newStreamServer(t, "isr")
+
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	// ISR pages are cached whole, so their deferred sections render in
+	// place and a failing one fails the page.
+	if rec.Code != http.StatusInternalServerError || !strings.Contains(rec.Body.String(), "error: query failed") {
+		t.Errorf("GET / = %d %q, want 500 with the error page", rec.Code, rec.Body.String())
+	}
+}
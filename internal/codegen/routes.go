@@ -0,0 +1,790 @@
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/brattlof/zeptor/internal/app/config"
+	"github.com/brattlof/zeptor/internal/app/router"
+)
+
+const RoutesFile = "zeptor_routes_gen.go"
+
+// FrameworkModule is the module whose internal packages generated code
+// imports. Go only allows that from within it, so apps must live there for
+// now, as the examples do.
+const FrameworkModule = "github.com/brattlof/zeptor"
+
+type Result struct {
+	File    string
+	URLs    string
+	Routes  int
+	Layouts int
+}
+
+type goPackage struct {
+	Name       string
+	ImportPath string
+	Alias      string
+	Funcs      map[string]*ast.FuncType
+	Vars       map[string]bool
+}
+
+type layoutBinding struct {
+	Dir  string
+	Call string
+	Load string
+}
+
+type boundaryBinding struct {
+	Kind string
+	Dir  string
+	Call string
+}
+
+type metadataBinding struct {
+	Dir   string
+	Value string
+}
+
+type middlewareBinding struct {
+	Dir     string
+	Call    string
+	Matcher string
+}
+
+type routeBinding struct {
+	Pattern      string
+	API          bool
+	Method       string
+	Call         string
+	StaticParams string
+	Load         string
+}
+
+// propsBinding binds the parameter of a page or layout whose type is what
+// its loader returns.
+type propsBinding struct {
+	Type string
+	Expr string
+}
+
+var boundaryConsts = map[router.BoundaryKind]string{
+	router.BoundaryNotFound: "router.BoundaryNotFound",
+	router.BoundaryError:    "router.BoundaryError",
+	router.BoundaryLoading:  "router.BoundaryLoading",
+}
+
+var methodConsts = map[string]string{
+	http.MethodGet:     "http.MethodGet",
+	http.MethodHead:    "http.MethodHead",
+	http.MethodPost:    "http.MethodPost",
+	http.MethodPut:     "http.MethodPut",
+	http.MethodPatch:   "http.MethodPatch",
+	http.MethodDelete:  "http.MethodDelete",
+	http.MethodOptions: "http.MethodOptions",
+}
+
+// routeSet holds the registrations for one router: the main app, or with
+// Host set, an app served for that hostname.
+type routeSet struct {
+	Host        string
+	Layouts     []layoutBinding
+	Boundaries  []boundaryBinding
+	Middlewares []middlewareBinding
+	Metadata    []metadataBinding
+	Routes      []routeBinding
+}
+
+type routesFile struct {
+	routeSet
+	Package     string
+	NeedContext bool
+	NeedHTTP    bool
+	NeedRender  bool
+	Imports     []*goPackage
+	Hosts       []*routeSet
+}
+
+var (
+	templDecl    = regexp.MustCompile(`(?m)^templ\s+(\w+)\((.*)\)\s*\{`)
+	packageDecl  = regexp.MustCompile(`(?m)^package\s+(\w+)`)
+	moduleDecl   = regexp.MustCompile(`(?m)^module\s+(\S+)`)
+	importPathOK = regexp.MustCompile(`^[A-Za-z0-9._~-]+$`)
+)
+
+var reservedIdents = map[string]bool{
+	"http":   true,
+	"render": true,
+	"router": true,
+	"rt":     true,
+	"r":      true,
+}
+
+// GenerateRoutes writes the registration code for the app in appDir and
+// for every host that is served from its own app directory.
+func GenerateRoutes(appDir, outFile string, hosts ...config.HostConfig) (*Result, error) {
+	rt, err := router.New(appDir)
+	if err != nil {
+		return nil, fmt.Errorf("discover routes: %w", err)
+	}
+	for _, h := range hosts {
+		if err := rt.AddHost(h.Host, h.AppDir, h.Prefix); err != nil {
+			return nil, fmt.Errorf("discover routes: %w", err)
+		}
+	}
+
+	absOut, err := filepath.Abs(outFile)
+	if err != nil {
+		return nil, err
+	}
+
+	modPath, modRoot, err := findModule(filepath.Dir(absOut))
+	if err != nil {
+		return nil, err
+	}
+	if modPath != FrameworkModule && !strings.HasPrefix(modPath, FrameworkModule+"/") {
+		return nil, fmt.Errorf("module %s cannot import %s/internal, which generated code needs; build the app inside the %s module", modPath, FrameworkModule, FrameworkModule)
+	}
+
+	data := &routesFile{
+		Package: outputPackage(filepath.Dir(absOut), filepath.Base(absOut)),
+	}
+
+	packages := make(map[string]*goPackage)
+	aliases := make(map[string]bool)
+
+	packageFor := func(file string) (*goPackage, error) {
+		dir := filepath.Dir(file)
+		if pkg, ok := packages[dir]; ok {
+			return pkg, nil
+		}
+
+		pkg, err := loadPackage(dir, modPath, modRoot)
+		if err != nil {
+			return nil, err
+		}
+		pkg.Alias = uniqueAlias(pkg.Name, aliases)
+		packages[dir] = pkg
+		data.Imports = append(data.Imports, pkg)
+		return pkg, nil
+	}
+
+	// Params captured from prefix hosts' hostnames reach routes in the
+	// main app, so pages there may bind them.
+	var hostParams []string
+	for _, h := range rt.Hosts() {
+		if h.Router == nil {
+			hostParams = append(hostParams, h.Params...)
+		}
+	}
+
+	routes, layouts := len(rt.Routes()), len(rt.Layouts())
+	if err := data.bind(&data.routeSet, rt, hostParams, packageFor); err != nil {
+		return nil, err
+	}
+	for _, h := range rt.Hosts() {
+		if h.Router == nil {
+			continue
+		}
+		set := &routeSet{Host: h.Pattern}
+		if err := data.bind(set, h.Router, h.Params, packageFor); err != nil {
+			return nil, err
+		}
+		data.Hosts = append(data.Hosts, set)
+		routes += len(h.Router.Routes())
+		layouts += len(h.Router.Layouts())
+	}
+
+	sort.Slice(data.Imports, func(i, j int) bool {
+		return data.Imports[i].ImportPath < data.Imports[j].ImportPath
+	})
+
+	var buf bytes.Buffer
+	if err := routesTemplate.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("execute template: %w", err)
+	}
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("format generated code: %w", err)
+	}
+
+	if err := os.WriteFile(absOut, src, 0644); err != nil {
+		return nil, fmt.Errorf("write %s: %w", outFile, err)
+	}
+
+	urls := filepath.Join(filepath.Dir(outFile), URLsDir, URLsFile)
+	if err := generateURLs(rt.Routes(), urls); err != nil {
+		return nil, err
+	}
+
+	return &Result{File: outFile, URLs: urls, Routes: routes, Layouts: layouts}, nil
+}
+
+// bind adds the registrations for rt's layouts, boundaries, middleware,
+// metadata and routes to set. hostParams are bindable in every page.
+func (data *routesFile) bind(set *routeSet, rt *router.Router, hostParams []string, packageFor func(string) (*goPackage, error)) error {
+	withMetadata := make(map[string]bool)
+	bindMetadata := func(dir string, pkg *goPackage) {
+		if pkg.Vars["Metadata"] && !withMetadata[dir] {
+			withMetadata[dir] = true
+			set.Metadata = append(set.Metadata, metadataBinding{Dir: dir, Value: pkg.Alias + ".Metadata"})
+		}
+	}
+
+	for _, layout := range rt.Layouts() {
+		pkg, err := packageFor(layout.File)
+		if err != nil {
+			return err
+		}
+
+		binding, err := bindLayout(layout, pkg, hostParams)
+		if err != nil {
+			return err
+		}
+
+		data.NeedHTTP, data.NeedRender = true, true
+		data.NeedContext = data.NeedContext || binding.Load != ""
+		set.Layouts = append(set.Layouts, binding)
+		bindMetadata(layout.Dir, pkg)
+	}
+
+	for _, boundary := range rt.Boundaries() {
+		pkg, err := packageFor(boundary.File)
+		if err != nil {
+			return err
+		}
+
+		binding, err := bindBoundary(boundary, pkg, hostParams)
+		if err != nil {
+			return err
+		}
+
+		data.NeedHTTP, data.NeedRender = true, true
+		set.Boundaries = append(set.Boundaries, binding)
+	}
+
+	for _, mw := range rt.Middlewares() {
+		pkg, err := packageFor(mw.File)
+		if err != nil {
+			return err
+		}
+
+		binding, err := bindMiddleware(mw, pkg)
+		if err != nil {
+			return err
+		}
+		set.Middlewares = append(set.Middlewares, binding)
+	}
+
+	for _, route := range rt.Routes() {
+		pkg, err := packageFor(route.File)
+		if err != nil {
+			return err
+		}
+
+		bindings, err := bindRoute(route, pkg, hostParams)
+		if err != nil {
+			return err
+		}
+		if route.Type == router.RouteTypePage {
+			bindMetadata(route.Dir, pkg)
+		}
+
+		for _, binding := range bindings {
+			if !binding.API {
+				data.NeedRender = true
+			}
+			if !binding.API || binding.Method != "" {
+				data.NeedHTTP = true
+			}
+			if binding.Load != "" {
+				data.NeedContext = true
+			}
+		}
+		set.Routes = append(set.Routes, bindings...)
+	}
+
+	return nil
+}
+
+func bindRoute(route *router.Route, pkg *goPackage, hostParams []string) ([]routeBinding, error) {
+	if route.Type == router.RouteTypeAPI {
+		return bindAPIRoute(route, pkg)
+	}
+
+	fn, ok := pkg.Funcs["Page"]
+	if !ok {
+		return nil, fmt.Errorf("%s: package %s does not declare Page", route.File, pkg.Name)
+	}
+
+	var props *propsBinding
+	binding := routeBinding{Pattern: route.Pattern}
+	if load, ok := pkg.Funcs["Load"]; ok {
+		typ, err := loaderType(route.File, "Load", load)
+		if err != nil {
+			return nil, err
+		}
+		binding.Load = pkg.Alias + ".Load"
+		props = &propsBinding{Type: typ, Expr: fmt.Sprintf("router.PageProps(r, %s.Load)", pkg.Alias)}
+	}
+
+	args, err := bindParams(route.File, "Page", slices.Concat(route.Params, hostParams), fn, props)
+	if err != nil {
+		return nil, err
+	}
+	binding.Call = fmt.Sprintf("%s.Page(%s)", pkg.Alias, strings.Join(args, ", "))
+	if fn, ok := pkg.Funcs["StaticParams"]; ok && route.IsDynamic {
+		binding.StaticParams, err = bindStaticParams(route.File, pkg, fn)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return []routeBinding{binding}, nil
+}
+
+// bindStaticParams adapts a page's StaticParams to router.StaticParamsFunc.
+// It may leave out the error.
+func bindStaticParams(file string, pkg *goPackage, fn *ast.FuncType) (string, error) {
+	call := pkg.Alias + ".StaticParams"
+	results := fieldTypes(fn.Results)
+	if len(fieldTypes(fn.Params)) == 0 && len(results) > 0 && results[0] == "[]map[string]string" {
+		switch {
+		case len(results) == 1:
+			return fmt.Sprintf("func() ([]map[string]string, error) {\n\t\treturn %s(), nil\n\t}", call), nil
+		case len(results) == 2 && results[1] == "error":
+			return call, nil
+		}
+	}
+	return "", fmt.Errorf("%s: StaticParams must have signature func() []map[string]string or func() ([]map[string]string, error)", file)
+}
+
+func bindAPIRoute(route *router.Route, pkg *goPackage) ([]routeBinding, error) {
+	if len(route.Methods) == 0 {
+		return nil, fmt.Errorf("%s: route.go must export at least one of GET, POST, PUT, PATCH, DELETE, HEAD or OPTIONS", route.File)
+	}
+
+	var bindings []routeBinding
+	for _, method := range route.Methods {
+		name, constant := method, methodConsts[method]
+		if method == router.AnyMethod {
+			name, constant = "Handler", ""
+		}
+
+		fn, ok := pkg.Funcs[name]
+		if !ok || !isHandlerFunc(fn) {
+			return nil, fmt.Errorf("%s: %s must have signature func(http.ResponseWriter, *http.Request)", route.File, name)
+		}
+
+		bindings = append(bindings, routeBinding{
+			Pattern: route.Pattern,
+			API:     true,
+			Method:  constant,
+			Call:    pkg.Alias + "." + name,
+		})
+	}
+
+	return bindings, nil
+}
+
+func bindLayout(layout *router.Layout, pkg *goPackage, hostParams []string) (layoutBinding, error) {
+	fn, ok := pkg.Funcs["Layout"]
+	if !ok {
+		return layoutBinding{}, fmt.Errorf("%s: package %s does not declare Layout", layout.File, pkg.Name)
+	}
+
+	var props *propsBinding
+	binding := layoutBinding{Dir: layout.Dir}
+	if load, ok := pkg.Funcs["LoadLayout"]; ok {
+		typ, err := loaderType(layout.File, "LoadLayout", load)
+		if err != nil {
+			return layoutBinding{}, err
+		}
+		binding.Load = pkg.Alias + ".LoadLayout"
+		props = &propsBinding{Type: typ, Expr: fmt.Sprintf("router.LayoutProps(r, %q, %s.LoadLayout)", layout.Dir, pkg.Alias)}
+	}
+
+	args, err := bindParams(layout.File, "Layout", slices.Concat(layout.Params, hostParams), fn, props)
+	if err != nil {
+		return layoutBinding{}, err
+	}
+	binding.Call = fmt.Sprintf("%s.Layout(%s)", pkg.Alias, strings.Join(args, ", "))
+	return binding, nil
+}
+
+// loaderType returns the type of the props a page's Load or a layout's
+// LoadLayout returns.
+func loaderType(file, name string, fn *ast.FuncType) (string, error) {
+	params, results := fieldTypes(fn.Params), fieldTypes(fn.Results)
+	if len(params) != 2 || params[0] != "context.Context" || params[1] != "*http.Request" ||
+		len(results) != 2 || results[1] != "error" {
+		return "", fmt.Errorf("%s: %s must have signature func(context.Context, *http.Request) (Props, error)", file, name)
+	}
+	return results[0], nil
+}
+
+func bindBoundary(boundary *router.Boundary, pkg *goPackage, hostParams []string) (boundaryBinding, error) {
+	name := boundary.Kind.Func()
+	fn, ok := pkg.Funcs[name]
+	if !ok {
+		return boundaryBinding{}, fmt.Errorf("%s: package %s does not declare %s", boundary.File, pkg.Name, name)
+	}
+
+	args, err := bindParams(boundary.File, name, slices.Concat(boundary.Params, hostParams), fn, nil)
+	if err != nil {
+		return boundaryBinding{}, err
+	}
+
+	return boundaryBinding{
+		Kind: boundaryConsts[boundary.Kind],
+		Dir:  boundary.Dir,
+		Call: fmt.Sprintf("%s.%s(%s)", pkg.Alias, name, strings.Join(args, ", ")),
+	}, nil
+}
+
+func bindMiddleware(mw *router.Middleware, pkg *goPackage) (middlewareBinding, error) {
+	fn, ok := pkg.Funcs["Middleware"]
+	if !ok || !isMiddlewareFunc(fn) {
+		return middlewareBinding{}, fmt.Errorf("%s: Middleware must have signature func(http.Handler) http.Handler", mw.File)
+	}
+
+	binding := middlewareBinding{
+		Dir:  mw.Dir,
+		Call: pkg.Alias + ".Middleware",
+	}
+	if pkg.Vars["Config"] {
+		binding.Matcher = pkg.Alias + ".Config.Matcher"
+	}
+	return binding, nil
+}
+
+func bindParams(file, kind string, params []string, fn *ast.FuncType, props *propsBinding) ([]string, error) {
+	var args []string
+
+	for _, field := range fn.Params.List {
+		typ := types.ExprString(field.Type)
+		for _, name := range field.Names {
+			if props != nil && typ == props.Type {
+				args = append(args, props.Expr)
+				continue
+			}
+			arg, err := bindParam(file, kind, params, name.Name, typ)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+		}
+	}
+
+	return args, nil
+}
+
+func bindParam(file, kind string, params []string, name, typ string) (string, error) {
+	switch typ {
+	case "*http.Request":
+		return "r", nil
+	case "context.Context":
+		return "r.Context()", nil
+	case "error":
+		return "router.RouteError(r)", nil
+	}
+
+	for _, param := range params {
+		if param != name {
+			continue
+		}
+		switch typ {
+		case "string":
+			return fmt.Sprintf("router.Param(r, %q)", name), nil
+		case "[]string":
+			return fmt.Sprintf("router.ParamSegments(r, %q)", name), nil
+		}
+	}
+
+	return "", fmt.Errorf("%s: cannot bind %s parameter %s %s (route params: %v)", file, kind, name, typ, params)
+}
+
+func isHandlerFunc(fn *ast.FuncType) bool {
+	params := fieldTypes(fn.Params)
+	return len(params) == 2 && params[0] == "http.ResponseWriter" && params[1] == "*http.Request" &&
+		len(fieldTypes(fn.Results)) == 0
+}
+
+func isMiddlewareFunc(fn *ast.FuncType) bool {
+	params, results := fieldTypes(fn.Params), fieldTypes(fn.Results)
+	return len(params) == 1 && params[0] == "http.Handler" && len(results) == 1 && results[0] == "http.Handler"
+}
+
+func fieldTypes(fields *ast.FieldList) []string {
+	if fields == nil {
+		return nil
+	}
+
+	var list []string
+	for _, field := range fields.List {
+		typ := types.ExprString(field.Type)
+		n := len(field.Names)
+		if n == 0 {
+			n = 1
+		}
+		for i := 0; i < n; i++ {
+			list = append(list, typ)
+		}
+	}
+	return list
+}
+
+func loadPackage(dir, modPath, modRoot string) (*goPackage, error) {
+	rel, err := filepath.Rel(modRoot, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	importPath := modPath
+	if rel != "." {
+		for _, elem := range strings.Split(filepath.ToSlash(rel), "/") {
+			if !importPathOK.MatchString(elem) || strings.HasPrefix(elem, "_") || strings.HasPrefix(elem, ".") {
+				return nil, fmt.Errorf("%s: directory %q is not a valid Go import path element (use slug_, slug__ or name.group instead of brackets)", dir, elem)
+			}
+		}
+		importPath += "/" + filepath.ToSlash(rel)
+	}
+
+	pkg := &goPackage{
+		ImportPath: importPath,
+		Funcs:      make(map[string]*ast.FuncType),
+		Vars:       make(map[string]bool),
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	fset := token.NewFileSet()
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+
+		file, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %w", filepath.Join(dir, name), err)
+		}
+
+		pkg.Name = file.Name.Name
+		for _, decl := range file.Decls {
+			switch decl := decl.(type) {
+			case *ast.FuncDecl:
+				if decl.Recv == nil && decl.Name.IsExported() {
+					pkg.Funcs[decl.Name.Name] = decl.Type
+				}
+			case *ast.GenDecl:
+				if decl.Tok != token.VAR {
+					continue
+				}
+				for _, spec := range decl.Specs {
+					for _, name := range spec.(*ast.ValueSpec).Names {
+						pkg.Vars[name.Name] = name.IsExported()
+					}
+				}
+			}
+		}
+	}
+
+	if err := loadTemplDecls(fset, dir, entries, pkg); err != nil {
+		return nil, err
+	}
+
+	if pkg.Name == "" {
+		return nil, fmt.Errorf("%s: no Go package found", dir)
+	}
+
+	return pkg, nil
+}
+
+// loadTemplDecls fills in components from .templ sources that have not been
+// generated yet, so routes can be wired before the first templ generate.
+func loadTemplDecls(fset *token.FileSet, dir string, entries []os.DirEntry, pkg *goPackage) error {
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".templ" {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		if pkg.Name == "" {
+			if m := packageDecl.FindSubmatch(content); m != nil {
+				pkg.Name = string(m[1])
+			}
+		}
+
+		var src strings.Builder
+		src.WriteString("package p\n")
+		for _, m := range templDecl.FindAllSubmatch(content, -1) {
+			if _, ok := pkg.Funcs[string(m[1])]; ok {
+				continue
+			}
+			fmt.Fprintf(&src, "func %s(%s) {}\n", m[1], m[2])
+		}
+
+		file, err := parser.ParseFile(fset, path, src.String(), parser.SkipObjectResolution)
+		if err != nil {
+			return fmt.Errorf("parse templ declarations in %s: %w", path, err)
+		}
+
+		for _, decl := range file.Decls {
+			if fn, ok := decl.(*ast.FuncDecl); ok && fn.Name.IsExported() {
+				pkg.Funcs[fn.Name.Name] = fn.Type
+			}
+		}
+	}
+
+	return nil
+}
+
+func findModule(dir string) (string, string, error) {
+	for {
+		content, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+		if err == nil {
+			m := moduleDecl.FindSubmatch(content)
+			if m == nil {
+				return "", "", fmt.Errorf("%s: no module directive", filepath.Join(dir, "go.mod"))
+			}
+			return string(m[1]), dir, nil
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", "", fmt.Errorf("go.mod not found")
+		}
+		dir = parent
+	}
+}
+
+func outputPackage(dir, skip string) string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "main"
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || name == skip || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(token.NewFileSet(), filepath.Join(dir, name), nil, parser.PackageClauseOnly)
+		if err == nil {
+			return file.Name.Name
+		}
+	}
+
+	return "main"
+}
+
+func uniqueAlias(name string, taken map[string]bool) string {
+	alias := name
+	for i := 2; taken[alias] || reservedIdents[alias]; i++ {
+		alias = fmt.Sprintf("%s%d", name, i)
+	}
+	taken[alias] = true
+	return alias
+}
+
+var routesTemplate = template.Must(template.New("routes").Parse(`// Code generated by zt generate routes. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+{{- if .NeedContext}}
+	"context"
+{{- end}}
+{{- if .NeedHTTP}}
+	"net/http"
+{{end}}
+{{- if .NeedRender}}
+	"github.com/brattlof/zeptor/internal/app/render"
+{{- end}}
+	"github.com/brattlof/zeptor/internal/app/router"
+{{range .Imports}}
+	{{.Alias}} "{{.ImportPath}}"
+{{- end}}
+)
+
+func registerRoutes(rt *router.Router) {
+{{- template "bindings" .}}
+{{- range .Hosts}}
+
+	if rt := rt.Host("{{.Host}}"); rt != nil {
+{{- template "bindings" .}}
+	}
+{{- end}}
+}
+{{define "bindings"}}
+{{- range .Layouts}}
+	rt.HandleLayout("{{.Dir}}", func(r *http.Request) (render.Component, error) {
+		return {{.Call}}, nil
+	})
+{{- if .Load}}
+	rt.HandleLayoutLoad("{{.Dir}}", func(ctx context.Context, r *http.Request) (any, error) {
+		return {{.Load}}(ctx, r)
+	})
+{{- end}}
+{{- end}}
+{{- range .Metadata}}
+	rt.HandleMetadata("{{.Dir}}", {{.Value}})
+{{- end}}
+{{- range .Middlewares}}
+{{- if .Matcher}}
+	if err := rt.HandleMiddleware("{{.Dir}}", {{.Call}}, {{.Matcher}}...); err != nil {
+		panic(err)
+	}
+{{- else}}
+	rt.HandleMiddleware("{{.Dir}}", {{.Call}})
+{{- end}}
+{{- end}}
+{{- range .Boundaries}}
+	rt.HandleBoundary({{.Kind}}, "{{.Dir}}", func(r *http.Request) (render.Component, error) {
+		return {{.Call}}, nil
+	})
+{{- end}}
+{{- range .Routes}}
+{{- if .Method}}
+	rt.HandleMethod("{{.Pattern}}", {{.Method}}, {{.Call}})
+{{- else if .API}}
+	rt.Handle("{{.Pattern}}", {{.Call}})
+{{- else}}
+	rt.HandlePage("{{.Pattern}}", func(r *http.Request) (render.Component, error) {
+		return {{.Call}}, nil
+	})
+{{- end}}
+{{- if .Load}}
+	rt.HandleLoad("{{.Pattern}}", func(ctx context.Context, r *http.Request) (any, error) {
+		return {{.Load}}(ctx, r)
+	})
+{{- end}}
+{{- if .StaticParams}}
+	rt.HandleStaticParams("{{.Pattern}}", {{.StaticParams}})
+{{- end}}
+{{- end}}
+{{- end}}
+`))
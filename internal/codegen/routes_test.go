@@ -0,0 +1,218 @@
+package codegen
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/brattlof/zeptor/internal/app/config"
+)
+
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestGenerateRoutes(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"go.mod":                      "module github.com/brattlof/zeptor/site\n\ngo 1.23\n",
+		"main.go":                     "package main\n\nfunc main() {}\n",
+		"app/page.templ":              "package app\n\ntempl Page() {\n\t<h1>Home</h1>\n}\n",
+		"app/layout.templ":            "package app\n\ntempl Layout() {\n\t<main>{ children... }</main>\n}\n",
+		"app/middleware.go":           "package app\n\nimport (\n\t\"net/http\"\n\n\t\"github.com/brattlof/zeptor/internal/app/router\"\n)\n\nvar Config = router.MiddlewareConfig{Matcher: []string{\"/blog/{...rest}\"}}\n\nfunc Middleware(next http.Handler) http.Handler { return next }\n",
+		"app/blog/middleware.go":      "package blog\n\nimport \"net/http\"\n\nfunc Middleware(next http.Handler) http.Handler { return next }\n",
+		"app/about/page.go":           "package about\n\nimport (\n\t\"github.com/a-h/templ\"\n\n\t\"github.com/brattlof/zeptor/internal/app/render\"\n)\n\nvar Metadata = render.Metadata{Title: \"About\"}\n\nfunc Page() templ.Component { return nil }\n",
+		"app/not-found.templ":         "package app\n\ntempl NotFound() {\n\t<h1>Not found</h1>\n}\n",
+		"app/blog/error.templ":        "package blog\n\ntempl Error(err error) {\n\t<p>{ err.Error() }</p>\n}\n",
+		"app/blog/slug_/layout.go":    "package slug_\n\nimport \"github.com/a-h/templ\"\n\nfunc Layout(slug string) templ.Component { return nil }\n",
+		"app/blog/slug_/page.templ":   "package slug_\n\ntempl Page(slug string) {\n\t<h1>{ slug }</h1>\n}\n",
+		"app/blog/slug_/static.go":    "package slug_\n\nfunc StaticParams() []map[string]string { return nil }\n",
+		"app/docs/slug___/page.templ": "package slug___\n\ntempl Page(slug []string) {\n\t<h1>{ slug[0] }</h1>\n}\n",
+		"app/api/users/route.go":      "package users\n\nimport \"net/http\"\n\nfunc GET(w http.ResponseWriter, r *http.Request) {}\n\nfunc POST(w http.ResponseWriter, r *http.Request) {}\n",
+		"app/api/legacy/route.go":     "package legacy\n\nimport \"net/http\"\n\nfunc Handler(w http.ResponseWriter, r *http.Request) {}\n",
+	})
+
+	out := filepath.Join(root, RoutesFile)
+	result, err := GenerateRoutes(filepath.Join(root, "app"), out)
+	if err != nil {
+		t.Fatalf("GenerateRoutes() error = %v", err)
+	}
+	if result.Routes != 6 || result.Layouts != 2 {
+		t.Errorf("Routes, Layouts = %d, %d, want 6, 2", result.Routes, result.Layouts)
+	}
+
+	src, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		"package main",
+		`app "github.com/brattlof/zeptor/site/app"`,
+		`slug_ "github.com/brattlof/zeptor/site/app/blog/slug_"`,
+		`rt.HandleLayout("/", func(r *http.Request) (render.Component, error) {`,
+		`return app.Layout(), nil`,
+		`return slug_.Layout(router.Param(r, "slug")), nil`,
+		`rt.HandleBoundary(router.BoundaryNotFound, "/", func(r *http.Request) (render.Component, error) {`,
+		`return app.NotFound(), nil`,
+		`rt.HandleBoundary(router.BoundaryError, "/blog", func(r *http.Request) (render.Component, error) {`,
+		`return blog.Error(router.RouteError(r)), nil`,
+		`rt.HandleMetadata("/about", about.Metadata)`,
+		`rt.HandleMiddleware("/", app.Middleware, app.Config.Matcher...)`,
+		`rt.HandleMiddleware("/blog", blog.Middleware)`,
+		`rt.HandlePage("/", func(r *http.Request) (render.Component, error) {`,
+		`return slug_.Page(router.Param(r, "slug")), nil`,
+		`return slug___.Page(router.ParamSegments(r, "slug")), nil`,
+		`rt.HandleStaticParams("/blog/{slug}", func() ([]map[string]string, error) {`,
+		`return slug_.StaticParams(), nil`,
+		`rt.HandleMethod("/api/users", http.MethodGet, users.GET)`,
+		`rt.HandleMethod("/api/users", http.MethodPost, users.POST)`,
+		`rt.Handle("/api/legacy", legacy.Handler)`,
+	} {
+		if !strings.Contains(string(src), want) {
+			t.Errorf("generated code missing %q\n%s", want, src)
+		}
+	}
+
+	urls, err := os.ReadFile(filepath.Join(root, URLsDir, URLsFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		"package routes",
+		"func Home() string {",
+		`func Blog(slug string) string {`,
+		`return "/blog/" + router.EscapeParam("slug", "", slug)`,
+		`func Docs(slug []string) string {`,
+		`return "/docs" + router.EscapeSegments(slug)`,
+		"func APIUsers() string {",
+	} {
+		if !strings.Contains(string(urls), want) {
+			t.Errorf("generated URL helpers missing %q\n%s", want, urls)
+		}
+	}
+}
+
+func TestGenerateRoutes_UnboundParam(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"go.mod":             "module github.com/brattlof/zeptor/site\n\ngo 1.23\n",
+		"app/id_/page.templ": "package id_\n\ntempl Page(slug string) {\n\t<h1>{ slug }</h1>\n}\n",
+	})
+
+	_, err := GenerateRoutes(filepath.Join(root, "app"), filepath.Join(root, RoutesFile))
+	if err == nil || !strings.Contains(err.Error(), "cannot bind Page parameter slug") {
+		t.Errorf("GenerateRoutes() error = %v, want unbound parameter error", err)
+	}
+}
+
+func TestGenerateRoutes_OutsideModule(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"go.mod":         "module example.com/site\n\ngo 1.23\n",
+		"app/page.templ": "package app\n\ntempl Page() {\n\t<h1>Home</h1>\n}\n",
+	})
+
+	_, err := GenerateRoutes(filepath.Join(root, "app"), filepath.Join(root, RoutesFile))
+	if err == nil || !strings.Contains(err.Error(), "module example.com/site cannot import github.com/brattlof/zeptor/internal") {
+		t.Errorf("GenerateRoutes() error = %v, want module error", err)
+	}
+}
+
+func TestGenerateRoutes_Hosts(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"go.mod":                 "module github.com/brattlof/zeptor/site\n\ngo 1.23\n",
+		"app/page.templ":         "package app\n\ntempl Page() {\n\t<h1>Home</h1>\n}\n",
+		"app/tenants/page.templ": "package tenants\n\ntempl Page(tenant string) {\n\t<h1>{ tenant }</h1>\n}\n",
+		"docs/page.templ":        "package docs\n\ntempl Page() {\n\t<h1>Docs</h1>\n}\n",
+		"docs/guide/page.templ":  "package guide\n\ntempl Page() {\n\t<h1>Guide</h1>\n}\n",
+	})
+
+	out := filepath.Join(root, RoutesFile)
+	result, err := GenerateRoutes(filepath.Join(root, "app"), out,
+		config.HostConfig{Host: "docs.example.com", AppDir: filepath.Join(root, "docs")},
+		config.HostConfig{Host: "{tenant}.example.com", Prefix: "/tenants"},
+	)
+	if err != nil {
+		t.Fatalf("GenerateRoutes() error = %v", err)
+	}
+	if result.Routes != 4 {
+		t.Errorf("Routes = %d, want 4", result.Routes)
+	}
+
+	src, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		`return tenants.Page(router.Param(r, "tenant")), nil`,
+		`if rt := rt.Host("docs.example.com"); rt != nil {`,
+		`return guide.Page(), nil`,
+	} {
+		if !strings.Contains(string(src), want) {
+			t.Errorf("generated code missing %q\n%s", want, src)
+		}
+	}
+}
+
+func TestGenerateRoutes_Loaders(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"go.mod":                    "module github.com/brattlof/zeptor/site\n\ngo 1.23\n",
+		"app/layout.templ":          "package app\n\ntempl Layout(nav Nav) {\n\t<main>{ children... }</main>\n}\n",
+		"app/load.go":               "package app\n\nimport (\n\t\"context\"\n\t\"net/http\"\n)\n\ntype Nav []string\n\nfunc LoadLayout(ctx context.Context, r *http.Request) (Nav, error) { return nil, nil }\n",
+		"app/blog/slug_/page.templ": "package slug_\n\ntempl Page(slug string, props *Props) {\n\t<h1>{ slug }</h1>\n}\n",
+		"app/blog/slug_/page.go":    "package slug_\n\nimport (\n\t\"context\"\n\t\"net/http\"\n)\n\ntype Props struct{ Title string }\n\nfunc Load(ctx context.Context, r *http.Request) (*Props, error) { return nil, nil }\n",
+	})
+
+	out := filepath.Join(root, RoutesFile)
+	if _, err := GenerateRoutes(filepath.Join(root, "app"), out); err != nil {
+		t.Fatalf("GenerateRoutes() error = %v", err)
+	}
+
+	src, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		`"context"`,
+		`return app.Layout(router.LayoutProps(r, "/", app.LoadLayout)), nil`,
+		`rt.HandleLayoutLoad("/", func(ctx context.Context, r *http.Request) (any, error) {`,
+		`return app.LoadLayout(ctx, r)`,
+		`return slug_.Page(router.Param(r, "slug"), router.PageProps(r, slug_.Load)), nil`,
+		`rt.HandleLoad("/blog/{slug}", func(ctx context.Context, r *http.Request) (any, error) {`,
+		`return slug_.Load(ctx, r)`,
+	} {
+		if !strings.Contains(string(src), want) {
+			t.Errorf("generated code missing %q\n%s", want, src)
+		}
+	}
+}
+
+func TestGenerateRoutes_InvalidLoader(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"go.mod":         "module github.com/brattlof/zeptor/site\n\ngo 1.23\n",
+		"app/page.templ": "package app\n\ntempl Page() {\n\t<h1>Home</h1>\n}\n",
+		"app/page.go":    "package app\n\nfunc Load() string { return \"\" }\n",
+	})
+
+	_, err := GenerateRoutes(filepath.Join(root, "app"), filepath.Join(root, RoutesFile))
+	if err == nil || !strings.Contains(err.Error(), "Load must have signature") {
+		t.Errorf("GenerateRoutes() error = %v, want loader signature error", err)
+	}
+}
@@ -0,0 +1,234 @@
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/brattlof/zeptor/internal/app/router"
+)
+
+const (
+	URLsDir  = "routes"
+	URLsFile = "routes_gen.go"
+)
+
+type urlHelper struct {
+	Name    string
+	Pattern string
+	Params  string
+	Body    string
+	// Checked helpers panic on values the route would not match.
+	Checked bool
+}
+
+type urlsFile struct {
+	NeedStrconv bool
+	NeedRouter  bool
+	Helpers     []urlHelper
+}
+
+var initialisms = map[string]string{
+	"api":  "API",
+	"html": "HTML",
+	"http": "HTTP",
+	"id":   "ID",
+	"json": "JSON",
+	"rss":  "RSS",
+	"url":  "URL",
+	"uuid": "UUID",
+}
+
+// generateURLs writes one helper per route to out, so templates can link
+// with routes.Blog(slug) instead of hardcoded paths.
+func generateURLs(routes []*router.Route, out string) error {
+	data := &urlsFile{}
+	names := make(map[string][]*router.Route)
+	for _, route := range routes {
+		name := helperName(route.Pattern, false)
+		names[name] = append(names[name], route)
+	}
+
+	taken := make(map[string]bool)
+	for _, route := range routes {
+		name := helperName(route.Pattern, false)
+		if len(names[name]) > 1 && route.IsDynamic {
+			name = helperName(route.Pattern, true)
+		}
+		for i := 2; taken[name]; i++ {
+			name = fmt.Sprintf("%s%d", helperName(route.Pattern, true), i)
+		}
+		taken[name] = true
+
+		helper := buildHelper(route.Pattern, data)
+		helper.Name = name
+		data.Helpers = append(data.Helpers, helper)
+	}
+
+	var buf bytes.Buffer
+	if err := urlsTemplate.Execute(&buf, data); err != nil {
+		return fmt.Errorf("execute template: %w", err)
+	}
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("format generated code: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(out), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(out, src, 0644); err != nil {
+		return fmt.Errorf("write %s: %w", out, err)
+	}
+	return nil
+}
+
+func buildHelper(pattern string, data *urlsFile) urlHelper {
+	var params, parts []string
+	var static strings.Builder
+	optionalRoot, checked := false, false
+
+	flush := func() {
+		if static.Len() > 0 {
+			parts = append(parts, fmt.Sprintf("%q", static.String()))
+			static.Reset()
+		}
+	}
+
+	for _, part := range strings.Split(strings.Trim(pattern, "/"), "/") {
+		if part == "" {
+			continue
+		}
+		if !strings.HasPrefix(part, "{") {
+			static.WriteString("/" + part)
+			continue
+		}
+
+		name, constraint, _ := strings.Cut(strings.Trim(part, "{}"), ":")
+		param := strings.TrimSuffix(strings.TrimPrefix(name, "..."), "?")
+		ident := paramIdent(param)
+		data.NeedRouter = true
+
+		switch {
+		case strings.HasSuffix(name, "?"):
+			flush()
+			params = append(params, ident+" []string")
+			parts = append(parts, fmt.Sprintf("router.EscapeSegments(%s)", ident))
+			optionalRoot = len(parts) == 1
+		case strings.HasPrefix(name, "..."):
+			flush()
+			params = append(params, ident+" []string")
+			parts = append(parts, fmt.Sprintf("router.EscapeCatchAll(%q, %s)", param, ident))
+			checked = true
+		case constraint == "int":
+			static.WriteString("/")
+			flush()
+			params = append(params, ident+" int")
+			parts = append(parts, fmt.Sprintf("router.EscapeParam(%q, %q, strconv.Itoa(%s))", param, constraint, ident))
+			data.NeedStrconv = true
+			checked = true
+		default:
+			static.WriteString("/")
+			flush()
+			params = append(params, ident+" string")
+			parts = append(parts, fmt.Sprintf("router.EscapeParam(%q, %q, %s)", param, constraint, ident))
+			checked = true
+		}
+	}
+	flush()
+
+	body := "return " + strings.Join(parts, " + ")
+	switch {
+	case len(parts) == 0:
+		body = `return "/"`
+	case optionalRoot:
+		ident := strings.Fields(params[0])[0]
+		body = fmt.Sprintf("if len(%s) == 0 {\n\treturn \"/\"\n}\n%s", ident, body)
+	}
+
+	return urlHelper{
+		Pattern: pattern,
+		Params:  strings.Join(params, ", "),
+		Body:    body,
+		Checked: checked,
+	}
+}
+
+// helperName derives an exported name from the static segments of pattern,
+// e.g. /blog/{slug} -> Blog, or BlogBySlug when qualified.
+func helperName(pattern string, qualified bool) string {
+	var name, by strings.Builder
+
+	for _, part := range strings.Split(strings.Trim(pattern, "/"), "/") {
+		if strings.HasPrefix(part, "{") {
+			param, _, _ := strings.Cut(strings.Trim(part, "{}"), ":")
+			by.WriteString(camel(strings.TrimSuffix(strings.TrimPrefix(param, "..."), "?")))
+			continue
+		}
+		name.WriteString(camel(part))
+	}
+
+	if name.Len() == 0 && by.Len() == 0 {
+		return "Home"
+	}
+	if name.Len() == 0 || qualified && by.Len() > 0 {
+		name.WriteString("By")
+		name.WriteString(by.String())
+	}
+
+	s := name.String()
+	if !unicode.IsLetter(rune(s[0])) {
+		s = "Route" + s
+	}
+	return s
+}
+
+func camel(s string) string {
+	var b strings.Builder
+	for _, word := range strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if up, ok := initialisms[strings.ToLower(word)]; ok {
+			b.WriteString(up)
+			continue
+		}
+		b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+	return b.String()
+}
+
+func paramIdent(name string) string {
+	if token.IsKeyword(name) || reservedIdents[name] || name == "url" || name == "strconv" {
+		return name + "Param"
+	}
+	return name
+}
+
+var urlsTemplate = template.Must(template.New("urls").Parse(`// Code generated by zt generate routes. DO NOT EDIT.
+
+package routes
+{{if .NeedRouter}}
+import (
+{{- if .NeedStrconv}}
+	"strconv"
+{{- end}}
+
+	"github.com/brattlof/zeptor/internal/app/router"
+)
+{{end}}
+{{- range .Helpers}}
+// {{.Name}} returns the URL for {{.Pattern}}.
+{{- if .Checked}} It panics if a value
+// cannot fill its segment.
+{{- end}}
+func {{.Name}}({{.Params}}) string {
+	{{.Body}}
+}
+{{end}}`))
@@ -1,26 +1,36 @@
 package dev
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"sync"
+	"time"
+
+	"github.com/brattlof/zeptor/internal/app/config"
+	"github.com/brattlof/zeptor/internal/app/router"
+	"github.com/brattlof/zeptor/internal/app/server"
+	"github.com/brattlof/zeptor/internal/codegen"
 )
 
 type Builder struct {
 	appDir  string
 	outDir  string
+	hosts   []config.HostConfig
 	mu      sync.Mutex
 	running bool
 }
 
-func NewBuilder(appDir, outDir string) *Builder {
+func NewBuilder(appDir, outDir string, hosts ...config.HostConfig) *Builder {
 	return &Builder{
 		appDir: appDir,
 		outDir: outDir,
+		hosts:  hosts,
 	}
 }
 
@@ -39,6 +49,75 @@ func (b *Builder) GenerateTempl(ctx context.Context) error {
 	return nil
 }
 
+func (b *Builder) GenerateRoutes(ctx context.Context) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, err := codegen.GenerateRoutes(b.appDir, codegen.RoutesFile, b.hosts...); err != nil {
+		return fmt.Errorf("generate routes: %w", err)
+	}
+
+	return nil
+}
+
+// WriteManifest writes router.ManifestFile for the app directory and its
+// hosts into dir and returns its path and the number of routes in it. The
+// message catalogs in messagesDir, when given, are copied beside it.
+func (b *Builder) WriteManifest(ctx context.Context, dir, messagesDir string) (string, int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	rt, err := router.New(b.appDir)
+	if err != nil {
+		return "", 0, fmt.Errorf("discover routes: %w", err)
+	}
+	for _, h := range b.hosts {
+		if err := rt.AddHost(h.Host, h.AppDir, h.Prefix); err != nil {
+			return "", 0, fmt.Errorf("discover routes: %w", err)
+		}
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", 0, fmt.Errorf("create out dir: %w", err)
+	}
+	path := filepath.Join(dir, router.ManifestFile)
+	f, err := os.Create(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+
+	if err := rt.WriteManifest(f); err != nil {
+		return "", 0, fmt.Errorf("write %s: %w", path, err)
+	}
+	if messagesDir != "" {
+		if err := b.copyCatalogs(dir, messagesDir); err != nil {
+			return "", 0, err
+		}
+	}
+	return path, len(rt.Routes()), f.Close()
+}
+
+// copyCatalogs replaces messagesDir under dir with the one in the app
+// directory, if there is one.
+func (b *Builder) copyCatalogs(dir, messagesDir string) error {
+	if !filepath.IsLocal(messagesDir) || filepath.Clean(messagesDir) == "." {
+		return fmt.Errorf("copy message catalogs: i18n.messagesDir %q is not a directory inside the app directory", messagesDir)
+	}
+
+	src, dst := filepath.Join(b.appDir, messagesDir), filepath.Join(dir, messagesDir)
+	if _, err := os.Stat(src); os.IsNotExist(err) {
+		return nil
+	}
+	if err := os.RemoveAll(dst); err != nil {
+		return err
+	}
+	if err := os.CopyFS(dst, os.DirFS(src)); err != nil {
+		return fmt.Errorf("copy message catalogs: %w", err)
+	}
+	return nil
+}
+
 func (b *Builder) GenerateEBPF(ctx context.Context) error {
 	b.mu.Lock()
 	defer b.mu.Unlock()
@@ -54,19 +133,101 @@ func (b *Builder) GenerateEBPF(ctx context.Context) error {
 	return nil
 }
 
+// BuildSSG builds the app in the current directory and runs it with
+// server.ExportEnv set, so that it pre-renders its pages into the out dir
+// and prints the build report.
 func (b *Builder) BuildSSG(ctx context.Context) error {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
-	if err := os.MkdirAll(b.outDir, 0755); err != nil {
+	outDir, err := filepath.Abs(b.outDir)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(outDir, 0755); err != nil {
 		return fmt.Errorf("create out dir: %w", err)
 	}
 
-	slog.Info("SSG build not yet implemented")
+	tmp, err := os.MkdirTemp("", "zeptor-ssg-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	bin := filepath.Join(tmp, "app")
+	build := exec.CommandContext(ctx, "go", "build", "-o", bin, ".")
+	build.Stdout = os.Stdout
+	build.Stderr = os.Stderr
+	if err := build.Run(); err != nil {
+		return fmt.Errorf("go build: %w", err)
+	}
 
+	out := &exportOutput{started: make(chan struct{})}
+	cmd := exec.CommandContext(ctx, bin)
+	cmd.Env = append(os.Environ(), server.ExportEnv+"="+outDir)
+	cmd.Stdout = out
+	cmd.Stderr = os.Stderr
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("pre-render pages: %w", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+
+	notExporting := errors.New("pre-render pages: the app did not start exporting; its main must call server.ExportIfRequested")
+	select {
+	case <-out.started:
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("pre-render pages: %w", err)
+		}
+		select {
+		case <-out.started:
+			return nil
+		default:
+			return notExporting
+		}
+	case <-time.After(exportStartTimeout):
+		cmd.Process.Kill()
+		<-done
+		return notExporting
+	}
+
+	if err := <-done; err != nil {
+		return fmt.Errorf("pre-render pages: %w", err)
+	}
 	return nil
 }
 
+// exportStartTimeout is how long BuildSSG waits for the app to announce
+// its export before taking it for one that just serves.
+const exportStartTimeout = 30 * time.Second
+
+// exportOutput passes the app's output through, watching for the line
+// server.ExportIfRequested prints before it exports.
+type exportOutput struct {
+	mu      sync.Mutex
+	line    []byte
+	started chan struct{}
+	seen    bool
+}
+
+func (o *exportOutput) Write(p []byte) (int, error) {
+	o.mu.Lock()
+	if !o.seen {
+		o.line = append(o.line, p...)
+		if bytes.Contains(o.line, []byte(server.ExportStarted)) {
+			o.seen = true
+			o.line = nil
+			close(o.started)
+		} else if i := bytes.LastIndexByte(o.line, '\n'); i >= 0 {
+			o.line = o.line[i+1:]
+		}
+	}
+	o.mu.Unlock()
+	return os.Stdout.Write(p)
+}
+
 func (b *Builder) BuildBinary(ctx context.Context, output string) error {
 	b.mu.Lock()
 	defer b.mu.Unlock()
@@ -21,6 +21,7 @@ import (
 
 	"github.com/brattlof/zeptor/internal/app/config"
 	"github.com/brattlof/zeptor/internal/app/router"
+	"github.com/brattlof/zeptor/internal/codegen"
 	"github.com/brattlof/zeptor/pkg/plugin"
 )
 
@@ -42,6 +43,11 @@ func NewDevServer(cfg *config.Config, registry *plugin.Registry) (*DevServer, er
 	if err != nil {
 		return nil, fmt.Errorf("create router: %w", err)
 	}
+	for _, h := range cfg.Hosts {
+		if err := rt.AddHost(h.Host, h.AppDir, h.Prefix); err != nil {
+			return nil, fmt.Errorf("create router: %w", err)
+		}
+	}
 
 	return &DevServer{
 		config:   cfg,
@@ -57,9 +63,13 @@ func (d *DevServer) Start(ctx context.Context) error {
 		slog.Warn("Initial build failed", "error", err)
 	}
 
-	d.watcher, _ = NewWatcher([]string{
-		d.config.Routing.AppDir,
-	}, d.handleFileChange)
+	dirs := []string{d.config.Routing.AppDir}
+	for _, h := range d.config.Hosts {
+		if h.AppDir != "" {
+			dirs = append(dirs, h.AppDir)
+		}
+	}
+	d.watcher, _ = NewWatcher(dirs, d.handleFileChange)
 
 	if err := d.watcher.Start(ctx); err != nil {
 		slog.Warn("File watcher failed", "error", err)
@@ -116,6 +126,10 @@ func (d *DevServer) buildAndStartChild(ctx context.Context) error {
 		d.childCmd.Wait()
 	}
 
+	if _, err := codegen.GenerateRoutes(d.config.Routing.AppDir, codegen.RoutesFile, d.config.Hosts...); err != nil {
+		slog.Warn("Route generation failed", "error", err)
+	}
+
 	slog.Info("Building example...")
 	buildCmd := exec.CommandContext(ctx, "go", "build", "-o", ".zeptor/server.exe", ".")
 	buildCmd.Stdout = os.Stdout
@@ -245,14 +259,15 @@ func (d *DevServer) handleFileChange(path string) {
 		d.rebuilding = false
 		d.childMu.Unlock()
 
+		d.reloadRoutes()
 		d.callDevReloadHooks(path)
 		d.hmr.Reload(path)
 
-	case ".go":
+	case ".go", ".yaml":
 		if strings.Contains(path, "_templ.go") {
 			return
 		}
-		slog.Info("Go file changed, rebuilding...", "file", path)
+		slog.Info("File changed, rebuilding...", "file", path)
 		d.childMu.Lock()
 		d.rebuilding = true
 		d.childMu.Unlock()
@@ -272,11 +287,25 @@ func (d *DevServer) handleFileChange(path string) {
 		d.rebuilding = false
 		d.childMu.Unlock()
 
+		d.reloadRoutes()
 		d.callDevReloadHooks(path)
 		d.hmr.Reload(path)
 	}
 }
 
+// reloadRoutes logs the routes a rebuild added, removed or changed. The
+// child serves them; this table only keeps the log in step with it.
+func (d *DevServer) reloadRoutes() {
+	diff, err := d.router.Reload()
+	if err != nil {
+		slog.Warn("Route reload failed", "error", err)
+		return
+	}
+	if !diff.Empty() {
+		slog.Info("Routes changed", "added", diff.Added, "removed", diff.Removed, "changed", diff.Changed)
+	}
+}
+
 func (d *DevServer) callDevReloadHooks(path string) {
 	if d.registry == nil {
 		return
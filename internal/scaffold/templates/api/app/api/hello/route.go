@@ -1,21 +1,14 @@
-package main
+package hello
 
 import (
 	"encoding/json"
 	"net/http"
 )
 
-func HelloHandler(w http.ResponseWriter, r *http.Request) {
+func GET(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"message": "Hello from {{.ProjectName}} API!",
 		"status":  "ok",
 	})
 }
-
-func HealthHandler(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{
-		"status": "healthy",
-	})
-}
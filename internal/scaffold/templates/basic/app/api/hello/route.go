@@ -1,11 +1,11 @@
-package main
+package hello
 
 import (
 	"encoding/json"
 	"net/http"
 )
 
-func HelloHandler(w http.ResponseWriter, r *http.Request) {
+func GET(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{
 		"message": "Hello from {{.ProjectName}} API!",